@@ -0,0 +1,153 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// Команды образуют дерево по словам имени: "system update check" — команда check
+// вложенного действия system/update, "system" и "system update" — группы.
+
+// resolveCommand объединяет начало аргументов в имя команды по самому длинному совпадению,
+// остальные аргументы передаются команде: [system update check -v] -> [system update check, -v]
+func resolveCommand(commands map[string]Command, args []string) []string {
+	for n := len(args); n > 1; n-- {
+		name := strings.Join(args[:n], " ")
+		if _, exists := commands[name]; exists {
+			return append([]string{name}, args[n:]...)
+		}
+	}
+	return args
+}
+
+// commandPrefix возвращает число первых аргументов, образующих известную команду или группу
+func commandPrefix(commands map[string]Command, args []string) int {
+	n := 0
+	for n < len(args) {
+		name := strings.Join(args[:n+1], " ")
+		if _, exists := commands[name]; !exists && !isGroup(commands, name) {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// childrenOf возвращает отсортированные слова, следующие за prefix: подкоманды и вложенные группы.
+// Пустой prefix — команды верхнего уровня.
+func childrenOf(commands map[string]Command, prefix string) []string {
+	seen := make(map[string]bool)
+	var children []string
+	for name := range commands {
+		rest := name
+		if prefix != "" {
+			var ok bool
+			if rest, ok = strings.CutPrefix(name, prefix+" "); !ok {
+				continue
+			}
+		}
+
+		child := strings.SplitN(rest, " ", 2)[0]
+		if !seen[child] {
+			seen[child] = true
+			children = append(children, child)
+		}
+	}
+	sort.Strings(children)
+	return children
+}
+
+// isGroup сообщает, есть ли у имени вложенные команды
+func isGroup(commands map[string]Command, name string) bool {
+	for key := range commands {
+		if strings.HasPrefix(key, name+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// descendantsOf возвращает отсортированные полные имена команд, вложенных в группу на любую глубину
+func descendantsOf(commands map[string]Command, name string) []string {
+	var names []string
+	for key := range commands {
+		if strings.HasPrefix(key, name+" ") {
+			names = append(names, key)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// groupAction возвращает любую команду действия с указанным именем, чтобы взять общее описание и каталог
+func groupAction(commands map[string]Command, name string) (Command, bool) {
+	for _, key := range descendantsOf(commands, name) {
+		if commands[key].Action == name {
+			return commands[key], true
+		}
+	}
+	return Command{}, false
+}
+
+// nodeDescription возвращает описание узла дерева: команды, действия с подкомандами или пустое для промежуточной группы
+func nodeDescription(commands map[string]Command, name string) string {
+	if command, exists := commands[name]; exists {
+		return command.Description
+	}
+	if action, exists := groupAction(commands, name); exists {
+		return action.GroupDescription
+	}
+	return ""
+}
+
+// commandLabel возвращает последнее слово имени с аргументами: "install <пакет...>"
+func commandLabel(name string, command Command) string {
+	words := strings.Split(name, " ")
+	last := words[len(words)-1]
+	if command.Settings == nil {
+		return last
+	}
+	// Строка использования подкоманды уже начинается с её имени
+	if command.Settings.Name != "" {
+		return command.Settings.usage()
+	}
+	return strings.TrimSpace(last + " " + command.Settings.usage())
+}
+
+// commandUsage возвращает полную строку использования команды: "atomic-actions apt install <пакет...>"
+func commandUsage(name string, command Command) string {
+	words := strings.Split(name, " ")
+	return strings.Join(append([]string{"atomic-actions"}, words[:len(words)-1]...), " ") + " " + commandLabel(name, command)
+}
+
+// treeRow строка иерархического списка команд
+type treeRow struct {
+	Depth       int
+	Name        string // Полное имя узла
+	Label       string // Последнее слово с аргументами
+	Description string
+	Runnable    bool // Узел — запускаемая команда, а не только группа
+}
+
+// commandTree возвращает узлы, вложенные в prefix, в порядке обхода в глубину
+func commandTree(commands map[string]Command, prefix string, depth int) []treeRow {
+	var rows []treeRow
+	for _, child := range childrenOf(commands, prefix) {
+		name := strings.TrimSpace(prefix + " " + child)
+		command, runnable := commands[name]
+
+		label := child
+		if runnable {
+			label = commandLabel(name, command)
+		}
+		rows = append(rows, treeRow{
+			Depth:       depth,
+			Name:        name,
+			Label:       label,
+			Description: nodeDescription(commands, name),
+			Runnable:    runnable,
+		})
+		rows = append(rows, commandTree(commands, name, depth+1)...)
+	}
+	return rows
+}
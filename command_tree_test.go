@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testCommands команды с вложенными действиями: apt с подкомандами и system/update с подкомандой check
+func testCommands() map[string]Command {
+	return map[string]Command{
+		"apt install":         {Action: "apt"},
+		"apt remove":          {Action: "apt"},
+		"game-mode":           {Action: "game-mode"},
+		"system update":       {Action: "system update"},
+		"system update check": {Action: "system update"},
+		"system info":         {Action: "system info"},
+	}
+}
+
+func TestResolveCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "команда без подкоманд", args: []string{"game-mode", "on"}, want: []string{"game-mode", "on"}},
+		{name: "подкоманда", args: []string{"apt", "install", "vim"}, want: []string{"apt install", "vim"}},
+		{name: "самое длинное совпадение", args: []string{"system", "update", "check", "-v"}, want: []string{"system update check", "-v"}},
+		{name: "вложенное действие", args: []string{"system", "update", "-v"}, want: []string{"system update", "-v"}},
+		{name: "группа без подкоманды", args: []string{"apt"}, want: []string{"apt"}},
+		{name: "неизвестная подкоманда", args: []string{"apt", "purge"}, want: []string{"apt", "purge"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveCommand(testCommands(), tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveCommand(%v) = %v, ожидалось %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandPrefix(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "пусто", args: nil, want: 0},
+		{name: "неизвестная команда", args: []string{"unknown"}, want: 0},
+		{name: "группа", args: []string{"apt"}, want: 1},
+		{name: "неизвестная подкоманда группы", args: []string{"apt", "purge"}, want: 1},
+		{name: "промежуточная группа", args: []string{"system", "update", "check", "--help"}, want: 3},
+		{name: "команда с аргументами", args: []string{"game-mode", "on"}, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commandPrefix(testCommands(), tt.args); got != tt.want {
+				t.Errorf("commandPrefix(%v) = %d, ожидалось %d", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChildrenOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		want   []string
+	}{
+		{name: "верхний уровень", prefix: "", want: []string{"apt", "game-mode", "system"}},
+		{name: "подкоманды", prefix: "apt", want: []string{"install", "remove"}},
+		{name: "вложенные группы", prefix: "system", want: []string{"info", "update"}},
+		{name: "вложенная подкоманда", prefix: "system update", want: []string{"check"}},
+		{name: "без вложенных", prefix: "game-mode", want: nil},
+		{name: "совпадение начала слова не считается", prefix: "sys", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := childrenOf(testCommands(), tt.prefix); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("childrenOf(%q) = %v, ожидалось %v", tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// completeCommand скрытая команда, которую вызывают скрипты автодополнения
+const completeCommand = "__complete"
+
+// completionScripts скрипты автодополнения, кандидаты запрашиваются у atomic-actions __complete
+var completionScripts = map[string]string{
+	"bash": `# bash completion for atomic-actions
+_atomic_actions() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local IFS=$'\n'
+    local candidates
+    candidates=$(atomic-actions __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null | cut -f1)
+    COMPREPLY=($(compgen -W "$candidates" -- "$cur"))
+}
+complete -o default -F _atomic_actions atomic-actions
+`,
+	"zsh": `#compdef atomic-actions
+# zsh completion for atomic-actions
+_atomic_actions() {
+    local -a candidates
+    local line
+    for line in "${(@f)$(atomic-actions __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}"; do
+        [[ -z "$line" ]] && continue
+        candidates+=("${${line%%$'\t'*}//:/\\:}:${line#*$'\t'}")
+    done
+    if (( ${#candidates} )); then
+        _describe 'atomic-actions' candidates
+    else
+        _files
+    fi
+}
+compdef _atomic_actions atomic-actions
+`,
+	"fish": `# fish completion for atomic-actions
+function __atomic_actions_complete
+    set -l tokens (commandline -opc)
+    set -l current (commandline -ct)
+    atomic-actions __complete $tokens[2..-1] "$current" 2>/dev/null
+end
+complete -c atomic-actions -f -a '(__atomic_actions_complete)'
+`,
+}
+
+// completionShells оболочки, для которых есть скрипт автодополнения
+func completionShells() []string {
+	var shells []string
+	for shell := range completionScripts {
+		shells = append(shells, shell)
+	}
+	sort.Strings(shells)
+	return shells
+}
+
+// completionCommand встроенная команда completion, печатает скрипт автодополнения
+func completionCommand() Command {
+	sudo := false
+	settings := &CommandSettings{
+		Description: "Скрипт автодополнения команд для bash, zsh или fish.",
+		Args: []ArgSettings{
+			{Name: "оболочка", Description: "Командная оболочка", Required: true, Choices: completionShells()},
+		},
+		Examples: []string{
+			"atomic-actions completion bash > /etc/bash_completion.d/atomic-actions",
+			"atomic-actions completion zsh > \"${fpath[1]}/_atomic-actions\"",
+			"atomic-actions completion fish > ~/.config/fish/completions/atomic-actions.fish",
+		},
+		Sudo: &sudo,
+	}
+
+	return Command{
+		Action:      "completion",
+		Description: settings.Description,
+		Settings:    settings,
+		Handler: func(args []string) {
+			if err := settings.checkArgs(args); err != nil {
+				fmt.Println(err)
+				fmt.Println("Использование: atomic-actions completion " + settings.usage())
+				os.Exit(2)
+			}
+			fmt.Print(completionScripts[args[0]])
+		},
+	}
+}
+
+// printCompletions выводит кандидатов для последнего слова в words: по строке "значение\tописание"
+func printCompletions(commands map[string]Command, words []string) {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current, previous := words[len(words)-1], words[:len(words)-1]
+
+	for _, candidate := range completionCandidates(commands, previous) {
+		value, _, _ := strings.Cut(candidate, "\t")
+		if strings.HasPrefix(value, current) {
+			fmt.Println(candidate)
+		}
+	}
+}
+
+// completionCandidates возвращает кандидатов для слова после previous: вложенные команды и группы
+// или значения аргумента команды, найденной по самому длинному совпадению
+func completionCandidates(commands map[string]Command, previous []string) []string {
+	n := commandPrefix(commands, previous)
+	name := strings.Join(previous[:n], " ")
+
+	var candidates []string
+	if n == len(previous) {
+		for _, child := range childrenOf(commands, name) {
+			candidates = append(candidates, completionLine(child, nodeDescription(commands, strings.TrimSpace(name+" "+child))))
+		}
+	}
+
+	// Аргументы после имени команды: n слов — имя, остальные — уже введённые аргументы
+	for ; n > 0; n-- {
+		name = strings.Join(previous[:n], " ")
+		if command, exists := commands[name]; exists {
+			return append(candidates, argumentCandidates(command, len(previous)-n)...)
+		}
+	}
+	return candidates
+}
+
+// argumentCandidates возвращает допустимые значения аргумента с номером index
+func argumentCandidates(command Command, index int) []string {
+	if command.Settings == nil || len(command.Settings.Args) == 0 {
+		return nil
+	}
+
+	args := command.Settings.Args
+	if index >= len(args) {
+		if !args[len(args)-1].Variadic {
+			return nil
+		}
+		index = len(args) - 1
+	}
+
+	var candidates []string
+	for _, choice := range args[index].Choices {
+		candidates = append(candidates, completionLine(choice, args[index].Description))
+	}
+	return candidates
+}
+
+// completionLine формирует строку кандидата, в описании остаётся только первая строка
+func completionLine(value string, description string) string {
+	if description = firstLine(description); description == "" {
+		return value
+	}
+	return value + "\t" + description
+}
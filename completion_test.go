@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// completionCommands команды с подкомандами и аргументами для дополнения
+func completionCommands() map[string]Command {
+	groupDescription := "Пакеты APT\nподробное описание"
+	return map[string]Command{
+		"apt install": {
+			Action:           "apt",
+			Description:      "Установка пакетов",
+			GroupDescription: groupDescription,
+			Settings: &CommandSettings{Args: []ArgSettings{
+				{Name: "пакет", Description: "Имя пакета", Variadic: true, Choices: []string{"vim", "git"}},
+			}},
+		},
+		"apt remove": {Action: "apt", Description: "Удаление пакетов", GroupDescription: groupDescription},
+		"game-mode": {
+			Action:      "game-mode",
+			Description: "Игровой режим",
+			Settings:    &CommandSettings{Args: []ArgSettings{{Name: "режим", Choices: []string{"on", "off"}}}},
+		},
+	}
+}
+
+func TestCompletionCandidates(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []string
+		want     []string
+	}{
+		{name: "действия", previous: nil, want: []string{"apt\tПакеты APT", "game-mode\tИгровой режим"}},
+		{name: "подкоманды", previous: []string{"apt"}, want: []string{"install\tУстановка пакетов", "remove\tУдаление пакетов"}},
+		{name: "аргумент подкоманды", previous: []string{"apt", "install"}, want: []string{"vim\tИмя пакета", "git\tИмя пакета"}},
+		{name: "повтор variadic", previous: []string{"apt", "install", "vim"}, want: []string{"vim\tИмя пакета", "git\tИмя пакета"}},
+		{name: "аргумент без описания", previous: []string{"game-mode"}, want: []string{"on", "off"}},
+		{name: "лишний аргумент", previous: []string{"game-mode", "on"}, want: nil},
+		{name: "подкоманда без аргументов", previous: []string{"apt", "remove"}, want: nil},
+		{name: "неизвестное действие", previous: []string{"docker"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := completionCandidates(completionCommands(), tt.previous); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("completionCandidates(%q) = %q, ожидалось %q", tt.previous, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompletionLine(t *testing.T) {
+	tests := []struct {
+		value       string
+		description string
+		want        string
+	}{
+		{"list", "", "list"},
+		{"list", "  Список команд  ", "list\tСписок команд"},
+		{"apt", "Пакеты APT\nподробное описание", "apt\tПакеты APT"},
+		{"apt", "\n", "apt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value+"/"+tt.description, func(t *testing.T) {
+			if got := completionLine(tt.value, tt.description); got != tt.want {
+				t.Errorf("completionLine(%q, %q) = %q, ожидалось %q", tt.value, tt.description, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"os"
+	"sort"
+	"strings"
+)
+
+// helpHeaderStyle стиль заголовков разделов справки по действию
+var helpHeaderStyle = lipgloss.NewStyle().Bold(true)
+
+// isHelpArg сообщает, что аргумент запрашивает справку
+func isHelpArg(arg string) bool {
+	return arg == "--help" || arg == "-h" || arg == "-help"
+}
+
+// describeSource возвращает каталог действия и его происхождение для справки
+func describeSource(source string) string {
+	kind := sourceKind(source)
+	if kind == "builtin" {
+		return describeKind(kind)
+	}
+	return source + " (" + describeKind(kind) + ")"
+}
+
+// describeSudo возвращает требование прав суперпользователя для справки
+func describeSudo(sudo bool) string {
+	if sudo {
+		return "требуются"
+	}
+	return "не требуются"
+}
+
+// printCommandHelp выводит справку по команде или группе с вложенными командами, false — имя не найдено
+func printCommandHelp(commands map[string]Command, name string) bool {
+	if command, exists := commands[name]; exists && command.Settings != nil {
+		printSubcommandHelp(commands, name, command)
+		return true
+	}
+
+	if !isGroup(commands, name) {
+		return false
+	}
+
+	header := "atomic-actions " + name + " <подкоманда>"
+	if description := nodeDescription(commands, name); description != "" {
+		fmt.Printf("%s\n\n%s\n\n", helpHeaderStyle.Render(header), description)
+	} else {
+		fmt.Printf("%s\n\n", helpHeaderStyle.Render(header))
+	}
+
+	fmt.Println(helpHeaderStyle.Render("Подкоманды:"))
+	allSudo, anySudo := printTreeRows(commands, name)
+
+	sudo := describeSudo(anySudo)
+	if anySudo && !allSudo {
+		sudo = "только для подкоманд с пометкой [root]"
+	}
+	// Промежуточная группа без settings.json своего каталога не имеет
+	if action, exists := groupAction(commands, name); exists {
+		fmt.Printf("\nИсточник: %s", describeSource(action.Source))
+	}
+	fmt.Printf("\nПрава суперпользователя: %s\n", sudo)
+	fmt.Printf("\nСправка по подкоманде: atomic-actions %s <подкоманда> --help\n", name)
+	return true
+}
+
+// printTreeRows выводит вложенные в prefix команды с отступом по глубине и сообщает,
+// требуют ли права суперпользователя все запускаемые команды и хотя бы одна из них
+func printTreeRows(commands map[string]Command, prefix string) (allSudo bool, anySudo bool) {
+	rows := commandTree(commands, prefix, 0)
+	width := 0
+	for _, row := range rows {
+		width = max(width, len([]rune(row.Label))+2*row.Depth)
+	}
+
+	allSudo = true
+	for _, row := range rows {
+		label := strings.Repeat("  ", row.Depth) + row.Label
+		line := fmt.Sprintf("  %s%s  %s", label, strings.Repeat(" ", width-len([]rune(label))), firstLine(row.Description))
+		if row.Runnable && commands[row.Name].Settings != nil {
+			sudo := *commands[row.Name].Settings.Sudo
+			allSudo, anySudo = allSudo && sudo, anySudo || sudo
+			if sudo {
+				line += " [root]"
+			}
+		}
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+	return allSudo, anySudo
+}
+
+// firstLine возвращает первую строку многострочного описания
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(line)
+}
+
+// printSubcommandHelp выводит описание, аргументы и примеры одной команды и вложенные в неё команды
+func printSubcommandHelp(commands map[string]Command, name string, command Command) {
+	settings := command.Settings
+	fmt.Printf("%s\n\n%s\n", helpHeaderStyle.Render(commandUsage(name, command)), settings.Description)
+
+	if len(settings.Args) > 0 {
+		fmt.Println("\n" + helpHeaderStyle.Render("Аргументы:"))
+		width := 0
+		for _, arg := range settings.Args {
+			width = max(width, len([]rune(arg.Name)))
+		}
+		for _, arg := range settings.Args {
+			var notes []string
+			if arg.Required {
+				notes = append(notes, "обязательный")
+			}
+			if arg.Variadic {
+				notes = append(notes, "можно указать несколько")
+			}
+			if len(arg.Choices) > 0 {
+				notes = append(notes, "значения: "+strings.Join(arg.Choices, ", "))
+			}
+
+			description := arg.Description
+			if len(notes) > 0 {
+				description = strings.TrimSpace(description + " (" + strings.Join(notes, "; ") + ")")
+			}
+			line := fmt.Sprintf("  %-*s  %s", width, arg.Name, description)
+			fmt.Println(strings.TrimRight(line, " "))
+		}
+	}
+
+	if len(settings.Examples) > 0 {
+		fmt.Println("\n" + helpHeaderStyle.Render("Примеры:"))
+		for _, example := range settings.Examples {
+			fmt.Println("  " + example)
+		}
+	}
+
+	if isGroup(commands, name) {
+		fmt.Println("\n" + helpHeaderStyle.Render("Вложенные команды:"))
+		printTreeRows(commands, name)
+	}
+
+	fmt.Printf("\nИсточник: %s\nПрава суперпользователя: %s\n", describeSource(command.Source), describeSudo(*settings.Sudo))
+}
+
+// printUnknownCommand сообщает о неизвестной команде или подкоманде и предлагает близкие по написанию.
+// Группа, вызванная без подкоманды, выводит свою справку.
+func printUnknownCommand(commands map[string]Command, args []string) {
+	n := commandPrefix(commands, args)
+	group := strings.Join(args[:n], " ")
+	if n > 0 && n == len(args) {
+		printCommandHelp(commands, group)
+		return
+	}
+
+	if n > 0 {
+		fmt.Printf("Неизвестная подкоманда %s в %s\n", args[n], group)
+		printSuggestions(args[n], childrenOf(commands, group), "atomic-actions "+group+" ")
+		fmt.Printf("Список подкоманд: atomic-actions %s --help\n", group)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Неизвестная команда: %s\n", args[0])
+	printSuggestions(args[0], childrenOf(commands, ""), "atomic-actions ")
+	fmt.Println("Список команд: atomic-actions -h")
+	os.Exit(1)
+}
+
+// printSuggestions выводит варианты, отличающиеся от введённого не более чем на треть длины
+func printSuggestions(input string, candidates []string, prefix string) {
+	type suggestion struct {
+		name     string
+		distance int
+	}
+
+	limit := max(2, len([]rune(input))/3)
+	var suggestions []suggestion
+	for _, candidate := range candidates {
+		distance := levenshtein(input, candidate)
+		// Префикс тоже считается опечаткой: inst -> install
+		if distance <= limit || (len(input) >= 3 && strings.HasPrefix(candidate, input)) {
+			suggestions = append(suggestions, suggestion{candidate, distance})
+		}
+	}
+	if len(suggestions) == 0 {
+		return
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].distance != suggestions[j].distance {
+			return suggestions[i].distance < suggestions[j].distance
+		}
+		return suggestions[i].name < suggestions[j].name
+	})
+
+	fmt.Println("Возможно, вы имели в виду:")
+	for i, s := range suggestions {
+		if i == 3 {
+			break
+		}
+		fmt.Println("  " + prefix + s.name)
+	}
+}
+
+// levenshtein возвращает редакционное расстояние между строками в символах
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current := make([]int, len(rb)+1)
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(rb)]
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+// captureStdout возвращает всё, что функция вывела в stdout
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	f()
+	w.Close()
+	output, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(output)
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "apt", 3},
+		{"apt", "", 3},
+		{"apt", "apt", 0},
+		{"instal", "install", 1},
+		{"isntall", "install", 2},
+		{"kitten", "sitting", 3},
+		// Расстояние считается в символах, а не в байтах
+		{"пакет", "пакеты", 1},
+		{"пакет", "пакЕт", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := levenshtein(tt.a, tt.b); got != tt.want {
+				t.Errorf("levenshtein(%q, %q) = %d, ожидалось %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintSuggestions(t *testing.T) {
+	candidates := []string{"apt", "completion", "game-mode", "install-system", "jet-brains", "list", "which"}
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "опечатка",
+			input: "lsit",
+			want:  "Возможно, вы имели в виду:\n  atomic-actions list\n",
+		},
+		{
+			name:  "начало имени после близких по расстоянию",
+			input: "inst",
+			want:  "Возможно, вы имели в виду:\n  atomic-actions list\n  atomic-actions install-system\n",
+		},
+		{
+			name:  "одна замена",
+			input: "apx",
+			want:  "Возможно, вы имели в виду:\n  atomic-actions apt\n",
+		},
+		{
+			name:  "ничего похожего",
+			input: "docker",
+			want:  "",
+		},
+		{
+			name:  "короткий ввод не считается началом имени",
+			input: "ja",
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := captureStdout(t, func() { printSuggestions(tt.input, candidates, "atomic-actions ") })
+			if got != tt.want {
+				t.Errorf("printSuggestions(%q) вывел %q, ожидалось %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintSuggestionsLimit(t *testing.T) {
+	got := captureStdout(t, func() { printSuggestions("ab", []string{"aa", "ac", "ad", "ae", "xyz"}, "") })
+	want := "Возможно, вы имели в виду:\n  aa\n  ac\n  ad\n"
+	if got != want {
+		t.Errorf("вывод %q, ожидалось %q", got, want)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import "atomic-actions/models/installer"
+
+// installSystemCommand встроенная команда install-system, параметры установщика и справку по ним разбирает сам установщик
+func installSystemCommand() Command {
+	sudo := true
+	settings := &CommandSettings{
+		Description: "Установка Alt Atomic на диск \nВнимание! Блочное устройство не должно быть смонтировано в системе.\nДля установки в файл образа: --to-image out.raw|out.qcow2 --size 40G",
+		Usage:       "[параметры]",
+		Examples: []string{
+			"sudo atomic-actions install-system",
+			"sudo atomic-actions install-system --to-image out.qcow2 --size 40G",
+		},
+		Sudo: &sudo,
+	}
+
+	return Command{
+		Action:      "install-system",
+		Description: settings.Description,
+		Settings:    settings,
+		OwnHelp:     true,
+		Handler: func(args []string) {
+			installer.RunInstaller(args)
+		},
+	}
+}
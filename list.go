@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// listFormats форматы вывода команды list
+var listFormats = []string{"table", "json", "yaml"}
+
+// CommandInfo описание команды для внешних программ
+type CommandInfo struct {
+	Command          string        `json:"command"`           // Полное имя: "apt install"
+	Group            string        `json:"group"`             // Действие, для вложенных через пробел: "system update"
+	Subcommand       string        `json:"subcommand"`        // Подкоманда, пусто для действий без подкоманд
+	Description      string        `json:"description"`       // Описание подкоманды или действия
+	GroupDescription string        `json:"group_description"` // Описание действия
+	Usage            string        `json:"usage"`             // Строка использования
+	Args             []ArgSettings `json:"args"`              // Позиционные аргументы из settings.json
+	Sudo             bool          `json:"sudo"`              // Требуются права суперпользователя
+	Source           string        `json:"source"`            // path, user, admin, xdg, system или builtin
+	SourcePath       string        `json:"source_path"`       // Каталог действия
+	ScriptPath       string        `json:"script_path"`       // Запускаемый скрипт
+}
+
+// SearchPathInfo каталог поиска действий для внешних программ
+type SearchPathInfo struct {
+	ActionRoot
+	Exists bool `json:"exists"`
+}
+
+// CommandList вывод команды list: каталоги поиска по убыванию приоритета и найденные команды
+type CommandList struct {
+	SearchPaths []SearchPathInfo `json:"search_paths"`
+	Commands    []CommandInfo    `json:"commands"`
+}
+
+// listCommand встроенная команда list, выводит все команды в виде таблицы, JSON или YAML
+func listCommand(commands map[string]Command) Command {
+	sudo := false
+	settings := &CommandSettings{
+		Description: "Список всех команд в виде таблицы, JSON или YAML для внешних программ.",
+		Usage:       "[--format table|json|yaml] [--json]",
+		Examples: []string{
+			"atomic-actions list --format json",
+			"atomic-actions list --format yaml",
+		},
+		Sudo: &sudo,
+	}
+
+	return Command{
+		Action:      "list",
+		Description: settings.Description,
+		Settings:    settings,
+		Handler: func(args []string) {
+			flags := flag.NewFlagSet("list", flag.ContinueOnError)
+			format := flags.String("format", "table", "Формат вывода: "+strings.Join(listFormats, ", "))
+			asJson := flags.Bool("json", false, "То же, что --format json")
+			if err := flags.Parse(args); err != nil {
+				os.Exit(2)
+			}
+			if *asJson {
+				*format = "json"
+			}
+
+			if err := printCommandList(commands, *format); err != nil {
+				fmt.Println(err)
+				os.Exit(2)
+			}
+		},
+	}
+}
+
+// machineReadableList сообщает, что запрошен вывод list для программ: --json или --format json|yaml
+func machineReadableList(args []string) bool {
+	if len(args) == 0 || args[0] != "list" {
+		return false
+	}
+	for i, arg := range args[1:] {
+		value, hasValue := "", false
+		switch {
+		case arg == "--json" || arg == "-json":
+			return true
+		case arg == "--format" || arg == "-format":
+			if i+2 < len(args) {
+				value, hasValue = args[i+2], true
+			}
+		default:
+			for _, prefix := range []string{"--format=", "-format="} {
+				if rest, ok := strings.CutPrefix(arg, prefix); ok {
+					value, hasValue = rest, true
+				}
+			}
+		}
+		if hasValue && value != "table" {
+			return true
+		}
+	}
+	return false
+}
+
+// commandInfos возвращает описания всех команд, отсортированные по имени
+func commandInfos(commands map[string]Command) []CommandInfo {
+	var infos []CommandInfo
+	for name, command := range commands {
+		info := CommandInfo{
+			Command:          name,
+			Group:            command.Action,
+			Subcommand:       strings.TrimSpace(strings.TrimPrefix(name, command.Action)),
+			Description:      command.Description,
+			GroupDescription: command.GroupDescription,
+			Args:             []ArgSettings{},
+			Source:           sourceKind(command.Source),
+			SourcePath:       command.Source,
+		}
+		if info.GroupDescription == "" {
+			info.GroupDescription = command.Description
+		}
+		if command.Settings != nil {
+			info.Description = command.Settings.Description
+			info.Usage = command.Settings.usage()
+			info.Sudo = *command.Settings.Sudo
+			// Пустые списки выводятся как [], а не null
+			for _, arg := range command.Settings.Args {
+				if arg.Choices == nil {
+					arg.Choices = []string{}
+				}
+				info.Args = append(info.Args, arg)
+			}
+		}
+		if command.Source != "" {
+			info.ScriptPath = filepath.Join(command.Source, "main.sh")
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Command < infos[j].Command
+	})
+	return infos
+}
+
+// searchPathInfos возвращает каталоги поиска с признаком существования
+func searchPathInfos() []SearchPathInfo {
+	infos := []SearchPathInfo{}
+	for _, root := range actionRoots {
+		info, err := os.Stat(root.Path)
+		infos = append(infos, SearchPathInfo{ActionRoot: root, Exists: err == nil && info.IsDir()})
+	}
+	return infos
+}
+
+// printCommandList выводит команды в заданном формате
+func printCommandList(commands map[string]Command, format string) error {
+	list := CommandList{SearchPaths: searchPathInfos(), Commands: commandInfos(commands)}
+
+	switch format {
+	case "table":
+		printHelp(commands)
+		fmt.Println("Каталоги поиска действий по убыванию приоритета:")
+		for i, path := range list.SearchPaths {
+			line := fmt.Sprintf("  %d. %s (%s)", i+1, path.Path, describeKind(path.Kind))
+			if !path.Exists {
+				line += " — не найден"
+			}
+			fmt.Println(line)
+		}
+	case "json":
+		// Без экранирования HTML, иначе <пакет> в usage превращается в \u003cпакет\u003e
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(list); err != nil {
+			return fmt.Errorf("ошибка формирования JSON: %v", err)
+		}
+	case "yaml":
+		fmt.Print(commandListYaml(list))
+	default:
+		return fmt.Errorf("неизвестный формат %s, допустимо: %s", format, strings.Join(listFormats, ", "))
+	}
+	return nil
+}
+
+// commandListYaml формирует YAML с каталогами поиска и командами, строки записываются в двойных кавычках
+func commandListYaml(list CommandList) string {
+	var b strings.Builder
+	if len(list.SearchPaths) == 0 {
+		b.WriteString("search_paths: []\n")
+	} else {
+		b.WriteString("search_paths:\n")
+		for _, path := range list.SearchPaths {
+			fmt.Fprintf(&b, "  - path: %s\n", yamlString(path.Path))
+			fmt.Fprintf(&b, "    kind: %s\n", yamlString(path.Kind))
+			fmt.Fprintf(&b, "    exists: %t\n", path.Exists)
+		}
+	}
+
+	if len(list.Commands) == 0 {
+		b.WriteString("commands: []\n")
+		return b.String()
+	}
+
+	b.WriteString("commands:\n")
+	for _, info := range list.Commands {
+		fmt.Fprintf(&b, "  - command: %s\n", yamlString(info.Command))
+		fmt.Fprintf(&b, "    group: %s\n", yamlString(info.Group))
+		fmt.Fprintf(&b, "    subcommand: %s\n", yamlString(info.Subcommand))
+		fmt.Fprintf(&b, "    description: %s\n", yamlString(info.Description))
+		fmt.Fprintf(&b, "    group_description: %s\n", yamlString(info.GroupDescription))
+		fmt.Fprintf(&b, "    usage: %s\n", yamlString(info.Usage))
+		if len(info.Args) == 0 {
+			b.WriteString("    args: []\n")
+		} else {
+			b.WriteString("    args:\n")
+			for _, arg := range info.Args {
+				fmt.Fprintf(&b, "      - name: %s\n", yamlString(arg.Name))
+				fmt.Fprintf(&b, "        description: %s\n", yamlString(arg.Description))
+				fmt.Fprintf(&b, "        required: %t\n", arg.Required)
+				fmt.Fprintf(&b, "        variadic: %t\n", arg.Variadic)
+				fmt.Fprintf(&b, "        choices: %s\n", yamlList(arg.Choices))
+			}
+		}
+		fmt.Fprintf(&b, "    sudo: %t\n", info.Sudo)
+		fmt.Fprintf(&b, "    source: %s\n", yamlString(info.Source))
+		fmt.Fprintf(&b, "    source_path: %s\n", yamlString(info.SourcePath))
+		fmt.Fprintf(&b, "    script_path: %s\n", yamlString(info.ScriptPath))
+	}
+	return b.String()
+}
+
+// yamlString записывает строку в двойных кавычках, экранирование Go совместимо с YAML
+func yamlString(value string) string {
+	return strconv.Quote(value)
+}
+
+// yamlList записывает список строк в строчной форме: ["a", "b"]
+func yamlList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, yamlString(value))
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
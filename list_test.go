@@ -0,0 +1,143 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestYamlString(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", `""`},
+		{"apt install", `"apt install"`},
+		{"Установка <пакет>", `"Установка <пакет>"`},
+		{`кавычки "и" \ слэш`, `"кавычки \"и\" \\ слэш"`},
+		{"строка 1\nстрока 2", `"строка 1\nстрока 2"`},
+		{"key: value # комментарий", `"key: value # комментарий"`},
+		{"- [a, b]", `"- [a, b]"`},
+		{"tab\tи\x00", `"tab\tи\x00"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := yamlString(tt.value); got != tt.want {
+				t.Errorf("yamlString(%q) = %s, ожидалось %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandListYaml(t *testing.T) {
+	tests := []struct {
+		name string
+		list CommandList
+		want string
+	}{
+		{
+			name: "пустой список",
+			list: CommandList{},
+			want: "search_paths: []\ncommands: []\n",
+		},
+		{
+			name: "экранирование строк и пустые списки",
+			list: CommandList{
+				SearchPaths: []SearchPathInfo{{ActionRoot: ActionRoot{Path: "/usr/share/a: b", Kind: "system"}, Exists: true}},
+				Commands: []CommandInfo{{
+					Command:          "apt install",
+					Group:            "apt",
+					Subcommand:       "install",
+					Description:      "Установка \"пакетов\"\nв систему",
+					GroupDescription: "# не комментарий",
+					Usage:            "install <пакет...>",
+					Args: []ArgSettings{
+						{Name: "режим", Required: true, Choices: []string{"on", "o\"ff"}},
+						{Name: "пакет", Variadic: true, Choices: []string{}},
+					},
+					Sudo:       true,
+					Source:     "user",
+					SourcePath: "/home/u/apt",
+					ScriptPath: "/home/u/apt/main.sh",
+				}, {
+					Command: "list",
+					Group:   "list",
+					Args:    []ArgSettings{},
+					Source:  "builtin",
+				}},
+			},
+			want: `search_paths:
+  - path: "/usr/share/a: b"
+    kind: "system"
+    exists: true
+commands:
+  - command: "apt install"
+    group: "apt"
+    subcommand: "install"
+    description: "Установка \"пакетов\"\nв систему"
+    group_description: "# не комментарий"
+    usage: "install <пакет...>"
+    args:
+      - name: "режим"
+        description: ""
+        required: true
+        variadic: false
+        choices: ["on", "o\"ff"]
+      - name: "пакет"
+        description: ""
+        required: false
+        variadic: true
+        choices: []
+    sudo: true
+    source: "user"
+    source_path: "/home/u/apt"
+    script_path: "/home/u/apt/main.sh"
+  - command: "list"
+    group: "list"
+    subcommand: ""
+    description: ""
+    group_description: ""
+    usage: ""
+    args: []
+    sudo: false
+    source: "builtin"
+    source_path: ""
+    script_path: ""
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commandListYaml(tt.list); got != tt.want {
+				t.Errorf("commandListYaml() =\n%s\nожидалось\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMachineReadableList(t *testing.T) {
+	tests := []struct {
+		args string
+		want bool
+	}{
+		{"", false},
+		{"list", false},
+		{"list --format table", false},
+		{"list --json", true},
+		{"list --format json", true},
+		{"list --format yaml", true},
+		{"list --format=yaml", true},
+		{"list -format=json", true},
+		{"list --format", false},
+		{"which list --json", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			if got := machineReadableList(strings.Fields(tt.args)); got != tt.want {
+				t.Errorf("machineReadableList(%q) = %t, ожидалось %t", tt.args, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,33 +1,24 @@
 package main
 
 import (
-	"atomic-actions/models/installer"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
 	"io"
-	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"sort"
 	"strings"
 	"syscall"
 )
 
-const (
-	systemActionsPath = "/usr/local/share/atomic-actions/actions"
-	userActionsPath   = ".local/share/atomic-actions/actions"
-)
+const systemActionsPath = "/usr/local/share/atomic-actions/actions"
 
-type ActionSettings struct {
-	Commands    []string `json:"commands"`
-	Description string   `json:"description"`
-	Sudo        bool     `json:"sudo"`
-}
+// precedenceEnv переменная окружения с приоритетом действий: system (по умолчанию) — системные перекрывают пользовательские,
+// user — наоборот
+const precedenceEnv = "ATOMIC_ACTIONS_PRECEDENCE"
 
 func main() {
 	logFile := setLogger()
@@ -40,22 +31,33 @@ func main() {
 	// Аргументы командной строки
 	args := flag.Args()
 
-	// Пути к директориям с actions
-	systemPath := systemActionsPath
-	userPath := filepath.Join(os.Getenv("HOME"), userActionsPath)
+	// Предупреждения загрузки действий не должны попасть в вывод для программ: кандидаты автодополнения
+	// пишутся в журнал только в файл, JSON и YAML — в stderr и файл
+	completing := len(args) > 0 && args[0] == completeCommand
+	if completing {
+		log.SetOutput(logFile)
+	} else if machineReadableList(args) {
+		log.SetOutput(io.MultiWriter(os.Stderr, logFile))
+	}
 
-	// Загружаем команды из обоих путей
-	commands := mergeCommands(
-		loadActionsWithDescriptions(systemPath),
-		loadActionsWithDescriptions(userPath),
-	)
+	// Загружаем команды из всех каталогов поиска, действие с более высоким приоритетом перекрывает одноимённое целиком
+	precedence, err := actionsPrecedence()
+	if err != nil {
+		log.Printf("Предупреждение: %v\n", err)
+	}
+	actionRoots = searchRoots(precedence)
+	commands := loadActionRoots(actionRoots)
 
 	// Добавляем команду installer вручную
-	commands["install-system"] = Command{
-		Description: "Установка Alt Atomic на диск \nВнимание! Блочное устройство не должно быть смонтировано в системе.",
-		Handler: func(args []string) {
-			installer.RunInstaller()
-		},
+	commands["install-system"] = installSystemCommand()
+	commands["completion"] = completionCommand()
+	commands["list"] = listCommand(commands)
+	commands["which"] = whichCommand(commands, precedence)
+
+	// Скрытая команда для скриптов автодополнения
+	if completing {
+		printCompletions(commands, args[1:])
+		return
 	}
 
 	if *helpFlag || len(args) == 0 {
@@ -63,36 +65,69 @@ func main() {
 		return
 	}
 
-	// Обрабатываем аргументы: объединяем первую часть команды и подкоманду
-	if len(args) > 1 {
-		args[0] = fmt.Sprintf("%s %s", args[0], args[1])
-		args = append(args[:1], args[2:]...) // Убираем подкоманду из списка аргументов
+	args = resolveCommand(commands, args)
+	command, exists := commands[args[0]]
+
+	// Справка по действию или группе: atomic-actions <действие> [подкоманда...] --help,
+	// команды с OwnHelp разбирают --help сами
+	if !exists {
+		if n := commandPrefix(commands, args); n > 0 && n < len(args) && isHelpArg(args[n]) {
+			printCommandHelp(commands, strings.Join(args[:n], " "))
+			return
+		}
+		printUnknownCommand(commands, args)
+		return
+	}
+	if len(args) > 1 && isHelpArg(args[1]) && command.Settings != nil && !command.OwnHelp {
+		printCommandHelp(commands, args[0])
+		return
 	}
 
-	// Ищем команду в карте
-	if command, exists := commands[args[0]]; exists {
-		command.Handler(args[1:])
-	} else {
-		fmt.Printf("Неизвестная команда: %s\n", args[0])
-		printHelp(commands)
+	command.Handler(args[1:])
+}
+
+// actionsPrecedence возвращает приоритет действий из ATOMIC_ACTIONS_PRECEDENCE, по умолчанию system:
+// пользовательский каталог не должен подменять системные действия, которые запускаются через sudo
+func actionsPrecedence() (string, error) {
+	switch precedence := os.Getenv(precedenceEnv); precedence {
+	case "", "system":
+		return "system", nil
+	case "user":
+		return "user", nil
+	default:
+		return "system", fmt.Errorf("неизвестное значение %s=%s, используется system", precedenceEnv, precedence)
 	}
 }
 
-// mergeCommands объединяет команды из двух карт
-func mergeCommands(cmd1, cmd2 map[string]Command) map[string]Command {
+// mergeCommands объединяет команды двух источников, действия из primary перекрывают одноимённые
+// действия из secondary целиком, со всеми подкомандами
+func mergeCommands(primary, secondary map[string]Command) map[string]Command {
 	merged := make(map[string]Command)
+	sources := make(map[string]string)
 
 	// Добавляем команды из первой карты
-	for k, v := range cmd1 {
+	for k, v := range primary {
 		merged[k] = v
+		sources[v.Action] = v.Source
+	}
+
+	// Добавляем команды из второй карты, если действия с таким именем ещё нет.
+	// Имя может совпасть и у разных действий: подкоманда "system update" и вложенное действие system/update
+	shadowed := make(map[string][]string)
+	for k, v := range secondary {
+		_, actionExists := sources[v.Action]
+		if _, exists := merged[k]; !exists && !actionExists {
+			merged[k] = v
+		} else if actionExists && !containsString(shadowed[v.Action], v.Source) {
+			shadowed[v.Action] = append(shadowed[v.Action], append([]string{v.Source}, v.Shadows...)...)
+		}
 	}
 
-	// Добавляем команды из второй карты
-	for k, v := range cmd2 {
-		if _, exists := merged[k]; !exists {
+	// Запоминаем перекрытые каталоги, их показывает команда which
+	for k, v := range merged {
+		if sources, exists := shadowed[v.Action]; exists {
+			v.Shadows = append(append([]string{}, v.Shadows...), sources...)
 			merged[k] = v
-		} else {
-			log.Printf("Предупреждение: Команда %s дублируется. Используется версия из первой карты.\n", k)
 		}
 	}
 
@@ -100,33 +135,17 @@ func mergeCommands(cmd1, cmd2 map[string]Command) map[string]Command {
 }
 
 type Command struct {
-	Description string
-	Handler     func(args []string)
+	Action           string           // Имя действия, для вложенных каталогов через пробел: "system update"
+	Description      string           // Описание команды или подкоманды
+	GroupDescription string           // Описание действия, общее для его подкоманд
+	Settings         *CommandSettings // Описание из settings.json или встроенной команды
+	Source           string           // Каталог действия, пусто для встроенных команд
+	Shadows          []string         // Каталоги одноимённых действий, перекрытых этим, по убыванию приоритета
+	OwnHelp          bool             // Команда сама выводит справку по --help, например со списком своих флагов
+	Handler          func(args []string)
 }
 
 func printHelp(commands map[string]Command) {
-	// Группируем команды
-	groupedCommands := make(map[string][]string)
-	descriptions := make(map[string]string)
-
-	for cmd, details := range commands {
-		parts := strings.SplitN(cmd, " ", 2)
-		if len(parts) > 1 {
-			groupedCommands[parts[0]] = append(groupedCommands[parts[0]], parts[1])
-			descriptions[parts[0]] = details.Description
-		} else {
-			groupedCommands[cmd] = []string{}
-			descriptions[cmd] = details.Description
-		}
-	}
-
-	// Сортируем группы команд по ключу (алфавитно)
-	keys := make([]string, 0, len(groupedCommands))
-	for key := range groupedCommands {
-		keys = append(keys, key)
-	}
-	sort.Strings(keys)
-
 	// Создаем стиль с отступами для ячеек
 	cellStyle := lipgloss.NewStyle().
 		PaddingLeft(1).
@@ -138,17 +157,16 @@ func printHelp(commands map[string]Command) {
 		BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("240"))).
 		Headers(cellStyle.Render("Команда"), cellStyle.Render("Описание"))
 
-	// Добавляем строки в таблицу
-	for _, group := range keys {
-		cmds := groupedCommands[group]
-		sort.Strings(cmds) // Сортируем подкоманды внутри группы
-		commandList := fmt.Sprintf("atomic-actions %s", group)
-		if len(cmds) > 0 {
-			commandList = fmt.Sprintf("atomic-actions %s %s", group, strings.Join(cmds, ", "))
+	// Добавляем строки в таблицу: действие или группа верхнего уровня с общим описанием,
+	// под ними с отступом по глубине вложенные группы и подкоманды со своими описаниями
+	for _, row := range commandTree(commands, "", 0) {
+		commandLine := strings.Repeat("  ", row.Depth) + row.Label
+		if row.Depth == 0 {
+			commandLine = "atomic-actions " + row.Label
 		}
 		t.Row(
-			cellStyle.Render(commandList),
-			cellStyle.Render(descriptions[group]),
+			cellStyle.Render(commandLine),
+			cellStyle.Render(row.Description),
 		)
 	}
 
@@ -183,26 +201,44 @@ func loadActionsWithDescriptions(basePath string) map[string]Command {
 
 			settingsPath := filepath.Join(path, "settings.json")
 			if _, err := os.Stat(settingsPath); err == nil {
+				// Имя действия — путь относительно корня поиска: system/update -> "system update"
+				relPath, err := filepath.Rel(basePath, path)
+				if err != nil || relPath == "." {
+					return nil
+				}
+				if strings.ContainsAny(relPath, " \t") {
+					log.Printf("Действие %s пропущено: пробелы в имени каталога не поддерживаются\n", path)
+					return nil
+				}
+				actionName := strings.ReplaceAll(relPath, string(filepath.Separator), " ")
+
 				settings, err := parseSettings(settingsPath)
 				if err != nil {
-					log.Printf("Ошибка чтения settings.json в %s: %v\n", path, err)
+					log.Printf("Действие %s пропущено, ошибка в settings.json (%s): %v\n", actionName, path, err)
 					return nil
 				}
 
-				dirName := filepath.Base(path)
 				if len(settings.Commands) > 0 {
 					for _, cmd := range settings.Commands {
-						commandName := fmt.Sprintf("%s %s", dirName, cmd)
-						commands[commandName] = Command{
-							Description: settings.Description,
-							Handler:     generateActionHandler(dirName, cmd, settings.Sudo),
-						}
+						command := settings.subcommand(cmd)
+						addActionCommand(commands, fmt.Sprintf("%s %s", actionName, cmd.Name), Command{
+							Action:           actionName,
+							Description:      command.Description,
+							GroupDescription: settings.Description,
+							Settings:         &command,
+							Source:           path,
+							Handler:          generateActionHandler(actionName, path, command),
+						})
 					}
 				} else {
-					commands[dirName] = Command{
+					command := settings.action()
+					addActionCommand(commands, actionName, Command{
+						Action:      actionName,
 						Description: settings.Description,
-						Handler:     generateActionHandler(dirName, "", settings.Sudo),
-					}
+						Settings:    &command,
+						Source:      path,
+						Handler:     generateActionHandler(actionName, path, command),
+					})
 				}
 			}
 		}
@@ -212,43 +248,34 @@ func loadActionsWithDescriptions(basePath string) map[string]Command {
 	return commands
 }
 
-func parseSettings(settingsPath string) (*ActionSettings, error) {
-	data, err := ioutil.ReadFile(settingsPath)
-	if err != nil {
-		return nil, err
-	}
-
-	var settings ActionSettings
-	if err := json.Unmarshal(data, &settings); err != nil {
-		return nil, err
+// addActionCommand добавляет команду действия, при совпадении имён в одном каталоге поиска
+// остаётся вложенное действие: его каталог обходится позже подкоманд родителя
+func addActionCommand(commands map[string]Command, name string, command Command) {
+	if existing, exists := commands[name]; exists {
+		log.Printf("Предупреждение: команда %s из %s перекрыта действием из %s\n", name, existing.Source, command.Source)
 	}
-
-	return &settings, nil
+	commands[name] = command
 }
 
-func generateActionHandler(action, command string, useSudo bool) func(args []string) {
+// generateActionHandler создаёт обработчик, запускающий main.sh из каталога, откуда загружено действие
+func generateActionHandler(action string, actionPath string, command CommandSettings) func(args []string) {
 	return func(args []string) {
-		scriptPaths := []string{
-			filepath.Join(systemActionsPath, action, "main.sh"),
-			filepath.Join(os.Getenv("HOME"), userActionsPath, action, "main.sh"),
-		}
-
-		var scriptPath string
-		for _, path := range scriptPaths {
-			if _, err := os.Stat(path); err == nil {
-				scriptPath = path
-				break
-			}
+		if err := command.checkArgs(args); err != nil {
+			fmt.Println(err)
+			fmt.Println(strings.TrimSpace("Использование: atomic-actions " + action + " " + command.usage()))
+			fmt.Printf("Подробнее: atomic-actions %s --help\n", strings.TrimSpace(action+" "+command.Name))
+			os.Exit(2)
 		}
 
-		if scriptPath == "" {
-			log.Printf("Скрипт для действия %s не найден в доступных директориях\n", action)
+		scriptPath := filepath.Join(actionPath, "main.sh")
+		if _, err := os.Stat(scriptPath); err != nil {
+			log.Printf("Скрипт для действия %s не найден: %s\n", action, scriptPath)
 			return
 		}
 
 		// Формируем команду для запуска
-		cmdArgs := append([]string{scriptPath, command}, args...)
-		if useSudo {
+		cmdArgs := append([]string{scriptPath, command.Name}, args...)
+		if *command.Sudo {
 			if syscall.Geteuid() != 0 {
 				log.Println("Команда должна быть запущена с правами суперпользователя!")
 				os.Exit(1)
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeCommands(t *testing.T) {
+	tests := []struct {
+		name      string
+		primary   map[string]Command
+		secondary map[string]Command
+		want      map[string]Command
+	}{
+		{
+			name:      "разные действия объединяются",
+			primary:   map[string]Command{"apt install": {Action: "apt", Source: "/user/apt"}},
+			secondary: map[string]Command{"game-mode": {Action: "game-mode", Source: "/system/game-mode"}},
+			want: map[string]Command{
+				"apt install": {Action: "apt", Source: "/user/apt"},
+				"game-mode":   {Action: "game-mode", Source: "/system/game-mode"},
+			},
+		},
+		{
+			name: "действие перекрывается целиком, со всеми подкомандами",
+			primary: map[string]Command{
+				"apt install": {Action: "apt", Source: "/user/apt"},
+			},
+			secondary: map[string]Command{
+				"apt install": {Action: "apt", Source: "/system/apt"},
+				"apt remove":  {Action: "apt", Source: "/system/apt"},
+			},
+			want: map[string]Command{
+				"apt install": {Action: "apt", Source: "/user/apt", Shadows: []string{"/system/apt"}},
+			},
+		},
+		{
+			name: "перекрытые ранее каталоги сохраняются по убыванию приоритета",
+			primary: map[string]Command{
+				"apt install": {Action: "apt", Source: "/path/apt"},
+			},
+			secondary: map[string]Command{
+				"apt install": {Action: "apt", Source: "/user/apt", Shadows: []string{"/system/apt"}},
+			},
+			want: map[string]Command{
+				"apt install": {Action: "apt", Source: "/path/apt", Shadows: []string{"/user/apt", "/system/apt"}},
+			},
+		},
+		{
+			name: "подкоманда и вложенное действие с одним именем: остаётся приоритетная",
+			primary: map[string]Command{
+				"system update": {Action: "system", Source: "/user/system"},
+			},
+			secondary: map[string]Command{
+				"system update": {Action: "system update", Source: "/system/system/update"},
+			},
+			want: map[string]Command{
+				"system update": {Action: "system", Source: "/user/system"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeCommands(tt.primary, tt.secondary); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeCommands() = %+v, ожидалось %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package installer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// efiFallbackLoader путь загрузчика по умолчанию на ESP, который прошивка ищет без записи в NVRAM
+const efiFallbackLoader = "EFI/BOOT/BOOTX64.EFI"
+
+// biosBootCodeSize размер загрузочного кода в MBR, дальше идут подпись диска и таблица разделов
+const biosBootCodeSize = 440
+
+// usesBiosLayout сообщает, размечается ли диск с BIOS Boot Partition (режимы LEGACY и HYBRID)
+func usesBiosLayout(typeBoot string) bool {
+	return typeBoot == "LEGACY" || typeBoot == "HYBRID"
+}
+
+// ensureEfiFallback копирует загрузчик дистрибутива в EFI/BOOT, если bootupd не создал его сам
+func ensureEfiFallback(efiMountPoint string) error {
+	fallbackPath := filepath.Join(efiMountPoint, efiFallbackLoader)
+	if _, err := os.Stat(fallbackPath); err == nil {
+		return nil
+	}
+
+	// shim загружает grubx64.efi из своего каталога, поэтому при наличии shim копируем оба файла
+	var loaders []string
+	if shims, _ := filepath.Glob(filepath.Join(efiMountPoint, "EFI/*/shimx64.efi")); len(shims) > 0 {
+		vendorDir := filepath.Dir(shims[0])
+		loaders = []string{shims[0], filepath.Join(vendorDir, "grubx64.efi"), filepath.Join(vendorDir, "mmx64.efi")}
+	} else if grubs, _ := filepath.Glob(filepath.Join(efiMountPoint, "EFI/*/grubx64.efi")); len(grubs) > 0 {
+		loaders = []string{grubs[0]}
+	} else {
+		return fmt.Errorf("EFI загрузчик не найден на ESP")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fallbackPath), 0755); err != nil {
+		return fmt.Errorf("ошибка создания каталога %s: %v", filepath.Dir(fallbackPath), err)
+	}
+
+	log.Printf("Копирование %s в %s...\n", loaders[0], fallbackPath)
+	if err := copyFile(loaders[0], fallbackPath); err != nil {
+		return err
+	}
+
+	for _, loader := range loaders[1:] {
+		if _, err := os.Stat(loader); os.IsNotExist(err) {
+			continue
+		}
+		if err := copyFile(loader, filepath.Join(filepath.Dir(fallbackPath), filepath.Base(loader))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyHybridBoot проверяет, что на диске установлены и BIOS GRUB, и EFI загрузчик
+func verifyHybridBoot(disk string, partitions map[string]PartitionInfo) (err error) {
+	log.Println("Проверка загрузчиков BIOS и UEFI...")
+
+	// grub-install записывает загрузочный код в MBR, а core.img — в BIOS Boot Partition
+	bootCode, err := readBlockPrefix(disk, biosBootCodeSize)
+	if err != nil {
+		return err
+	}
+	if isZeroed(bootCode) {
+		return fmt.Errorf("загрузочный код BIOS GRUB отсутствует в MBR диска %s", disk)
+	}
+
+	coreImage, err := readBlockPrefix(partitions["bios"].Path, 512)
+	if err != nil {
+		return err
+	}
+	if isZeroed(coreImage) {
+		return fmt.Errorf("BIOS Boot Partition %s пуст, core.img GRUB не установлен", partitions["bios"].Path)
+	}
+
+	mountPoint := "/mnt/efi-check"
+	if err := mountDisk(partitions["efi"].Path, mountPoint, "ro"); err != nil {
+		return fmt.Errorf("ошибка монтирования EFI раздела: %v", err)
+	}
+	defer releaseMountPointInto(&err, mountPoint)
+
+	if _, err := os.Stat(filepath.Join(mountPoint, efiFallbackLoader)); err != nil {
+		return fmt.Errorf("EFI загрузчик %s отсутствует на ESP", efiFallbackLoader)
+	}
+
+	log.Println("Загрузчики BIOS и UEFI установлены.")
+	return nil
+}
+
+// readBlockPrefix читает первые size байт блочного устройства
+func readBlockPrefix(device string, size int) ([]byte, error) {
+	file, err := os.Open(device)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия %s: %v", device, err)
+	}
+	defer file.Close()
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s: %v", device, err)
+	}
+	return data, nil
+}
+
+// isZeroed сообщает, что блок заполнен нулями
+func isZeroed(data []byte) bool {
+	return len(bytes.Trim(data, "\x00")) == 0
+}
+
+// copyFile копирует файл с правами 0644, на vfat права всё равно не сохраняются
+func copyFile(src string, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения %s: %v", src, err)
+	}
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		return fmt.Errorf("ошибка записи %s: %v", dst, err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package installer
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// efiBootLabel имя записи в NVRAM, по нему находятся записи предыдущих установок
+const efiBootLabel = "Alt Atomic"
+
+// efiBootEntryPattern строка записи efibootmgr -v: Boot0003* Alt Atomic	HD(1,GPT,<partuuid>,...)/File(\EFI\...)
+var efiBootEntryPattern = regexp.MustCompile(`^Boot([0-9A-Fa-f]{4})\*?\s+(.*)$`)
+
+// efiPartUUIDPattern PARTUUID раздела в пути устройства записи
+var efiPartUUIDPattern = regexp.MustCompile(`HD\(\d+,GPT,([0-9A-Fa-f-]{36})`)
+
+// EfiBootEntry запись загрузки из NVRAM
+type EfiBootEntry struct {
+	Number   string // Номер записи, например 0003
+	Label    string // Отображаемое имя
+	PartUUID string // PARTUUID раздела ESP, пусто для записей без диска (сеть, оболочка)
+}
+
+// needsEfiBootEntry сообщает, нужна ли запись в NVRAM: только при установке на диск этой машины, загруженной через UEFI
+func needsEfiBootEntry(config *InstallConfig) bool {
+	return config.BootMode != "LEGACY" && !config.GenericImage && checkUEFISupport()
+}
+
+// listEfiBootEntries возвращает записи загрузки из NVRAM
+func listEfiBootEntries() ([]EfiBootEntry, error) {
+	output, err := exec.Command("efibootmgr", "-v").Output()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения efibootmgr: %v", err)
+	}
+	return parseEfiBootEntries(string(output)), nil
+}
+
+// parseEfiBootEntries разбирает вывод efibootmgr -v
+func parseEfiBootEntries(output string) []EfiBootEntry {
+	var entries []EfiBootEntry
+	for _, line := range strings.Split(output, "\n") {
+		match := efiBootEntryPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+
+		entry := EfiBootEntry{Number: match[1], Label: match[2]}
+		// Имя отделяется от пути устройства табуляцией, в старых версиях efibootmgr — пробелом перед путём
+		if i := strings.Index(entry.Label, "\t"); i >= 0 {
+			entry.Label = entry.Label[:i]
+		} else if i := strings.Index(entry.Label, " HD("); i >= 0 {
+			entry.Label = entry.Label[:i]
+		}
+		entry.Label = strings.TrimSpace(entry.Label)
+
+		if uuid := efiPartUUIDPattern.FindStringSubmatch(match[2]); uuid != nil {
+			entry.PartUUID = strings.ToLower(uuid[1])
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// getPartUUID возвращает PARTUUID раздела GPT
+func getPartUUID(partition string) (string, error) {
+	output, err := exec.Command("blkid", "-s", "PARTUUID", "-o", "value", partition).Output()
+	if err != nil {
+		return "", fmt.Errorf("ошибка получения PARTUUID для %s: %v", partition, err)
+	}
+	return strings.ToLower(strings.TrimSpace(string(output))), nil
+}
+
+// diskPartUUIDs возвращает PARTUUID всех разделов диска, ошибка не важна — на пустом диске разделов нет
+func diskPartUUIDs(disk string) map[string]bool {
+	partUUIDs := make(map[string]bool)
+	output, err := exec.Command("lsblk", "-ln", "-o", "PARTUUID", disk).Output()
+	if err != nil {
+		return partUUIDs
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if partUUID := strings.ToLower(strings.TrimSpace(line)); partUUID != "" {
+			partUUIDs[partUUID] = true
+		}
+	}
+	return partUUIDs
+}
+
+// findEfiLoader возвращает путь загрузчика на ESP в формате UEFI: shim, затем grub, затем резервный путь
+func findEfiLoader(efiMountPoint string) (string, error) {
+	for _, pattern := range []string{"EFI/*/shimx64.efi", "EFI/*/grubx64.efi", efiFallbackLoader} {
+		matches, _ := filepath.Glob(filepath.Join(efiMountPoint, pattern))
+		for _, match := range matches {
+			relative, err := filepath.Rel(efiMountPoint, match)
+			if err != nil {
+				continue
+			}
+			// Резервный EFI/BOOT подходит только как последний вариант
+			if pattern != efiFallbackLoader && strings.EqualFold(filepath.Base(filepath.Dir(match)), "BOOT") {
+				continue
+			}
+			return "\\" + strings.ReplaceAll(relative, "/", "\\"), nil
+		}
+	}
+	return "", fmt.Errorf("загрузчик не найден на ESP")
+}
+
+// createEfiBootEntry создаёт запись "Alt Atomic" для установленного ESP и удаляет записи прошлых установок на этот диск.
+// previousPartUUIDs — PARTUUID разделов диска до переразметки, записи других дисков не трогаются.
+func createEfiBootEntry(disk string, partitions map[string]PartitionInfo, previousPartUUIDs map[string]bool, keepBootOrder bool) error {
+	log.Println("Создание загрузочной записи UEFI...")
+
+	efi := partitions["efi"]
+	partUUID, err := getPartUUID(efi.Path)
+	if err != nil {
+		return err
+	}
+
+	mountPoint := "/mnt/efi-entry"
+	if err := mountDisk(efi.Path, mountPoint, "ro"); err != nil {
+		return fmt.Errorf("ошибка монтирования EFI раздела: %v", err)
+	}
+	loader, err := findEfiLoader(mountPoint)
+	if releaseErr := releaseMountPoint(mountPoint); releaseErr != nil {
+		log.Printf("Предупреждение: %v\n", releaseErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	entries, err := listEfiBootEntries()
+	if err != nil {
+		return err
+	}
+
+	// Записи прошлых установок указывают на ESP этого диска до переразметки или на этот же ESP
+	for _, entry := range entries {
+		if entry.Label != efiBootLabel || entry.PartUUID == "" {
+			continue
+		}
+		if entry.PartUUID == partUUID || previousPartUUIDs[entry.PartUUID] {
+			log.Printf("Удаление устаревшей загрузочной записи Boot%s...\n", entry.Number)
+			if err := runCommand("efibootmgr", "--quiet", "--bootnum", entry.Number, "--delete-bootnum"); err != nil {
+				return fmt.Errorf("ошибка удаления записи Boot%s: %v", entry.Number, err)
+			}
+		}
+	}
+
+	// --create ставит новую запись первой в BootOrder, --create-only оставляет порядок без изменений
+	createFlag := "--create"
+	if keepBootOrder {
+		createFlag = "--create-only"
+	}
+
+	if err := runCommand("efibootmgr", "--quiet", createFlag,
+		"--disk", disk, "--part", efi.Number,
+		"--label", efiBootLabel, "--loader", loader); err != nil {
+		return fmt.Errorf("ошибка создания загрузочной записи: %v", err)
+	}
+
+	log.Printf("Загрузочная запись %s создана: %s на разделе %s.\n", efiBootLabel, loader, efi.Path)
+	return nil
+}
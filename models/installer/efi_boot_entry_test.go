@@ -0,0 +1,48 @@
+package installer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEfiBootEntries(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []EfiBootEntry
+	}{
+		{name: "пустой вывод", output: "", want: nil},
+		{
+			name: "имя отделено табуляцией",
+			output: "BootCurrent: 0003\n" +
+				"Timeout: 1 seconds\n" +
+				"BootOrder: 0003,0001\n" +
+				"Boot0001* UEFI PXEv4\tPciRoot(0x0)/Pci(0x3,0x0)/MAC(525400123456,1)/IPv4(0.0.0.0,0,DHCP)\n" +
+				"Boot0003* Alt Atomic\tHD(1,GPT,0A1B2C3D-4E5F-6071-8293-A4B5C6D7E8F9,0x800,0x12c000)/File(\\EFI\\altlinux\\shimx64.efi)\n",
+			want: []EfiBootEntry{
+				{Number: "0001", Label: "UEFI PXEv4"},
+				{Number: "0003", Label: "Alt Atomic", PartUUID: "0a1b2c3d-4e5f-6071-8293-a4b5c6d7e8f9"},
+			},
+		},
+		{
+			name:   "старый efibootmgr: путь через пробел",
+			output: "Boot000A  Alt Atomic HD(2,GPT,0a1b2c3d-4e5f-6071-8293-a4b5c6d7e8f9,0x800,0x12c000)/File(\\EFI\\BOOT\\BOOTX64.EFI)\n",
+			want: []EfiBootEntry{
+				{Number: "000A", Label: "Alt Atomic", PartUUID: "0a1b2c3d-4e5f-6071-8293-a4b5c6d7e8f9"},
+			},
+		},
+		{
+			name:   "неактивная запись без пути",
+			output: "Boot0004 EFI Internal Shell\n",
+			want:   []EfiBootEntry{{Number: "0004", Label: "EFI Internal Shell"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseEfiBootEntries(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseEfiBootEntries() = %+v, ожидалось %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package installer
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// FilesystemDriver описывает операции установщика, зависящие от файловой системы root-раздела.
+// Чтобы добавить новую файловую систему, достаточно реализовать интерфейс и зарегистрировать его в filesystemDrivers.
+type FilesystemDriver interface {
+	// Name возвращает имя файловой системы, оно же используется как тип раздела в parted
+	Name() string
+	// Description возвращает пояснение для шага выбора файловой системы
+	Description() string
+	// Commands возвращает системные команды, необходимые для работы с файловой системой
+	Commands() []string
+	// Format форматирует root-раздел и выполняет начальную разметку (например, подтома)
+	Format(partition string) error
+	// RootMountOptions возвращает опции монтирования корня для установки bootc
+	RootMountOptions() string
+	// PopulateState переносит /var и /home из развёртывания ostree в постоянное хранилище
+	PopulateState(partition string, ostreeDeployPath string) error
+	// CreateSwapFile создаёт файл подкачки swapFilePath размером sizeMiB
+	CreateSwapFile(partition string, ostreeDeployPath string, sizeMiB int) error
+	// FstabEntries возвращает строки fstab для root-раздела
+	FstabEntries(uuid string) []string
+	// Grow расширяет файловую систему на весь root-раздел
+	Grow(partition string) error
+}
+
+// filesystemDrivers доступные файловые системы в порядке отображения
+var filesystemDrivers = []FilesystemDriver{
+	&btrfsDriver{Profile: defaultBtrfsProfile()},
+	&ext4Driver{},
+	&xfsDriver{},
+}
+
+// getFilesystemDriver возвращает драйвер по имени файловой системы
+func getFilesystemDriver(name string) (FilesystemDriver, error) {
+	for _, driver := range filesystemDrivers {
+		if driver.Name() == name {
+			return driver, nil
+		}
+	}
+	return nil, fmt.Errorf("неизвестная файловая система: %s", name)
+}
+
+// joinMountOptions объединяет непустые опции монтирования через запятую
+func joinMountOptions(options ...string) string {
+	result := ""
+	for _, option := range options {
+		if option == "" {
+			continue
+		}
+		if result != "" {
+			result += ","
+		}
+		result += option
+	}
+	return result
+}
+
+// populateStateroot переносит /home развёртывания в /var stateroot, используется файловыми системами без подтомов
+func populateStateroot(ostreeDeployPath string) error {
+	varDeployPath := filepath.Join(ostreeDeployPath, "../../var/home")
+
+	// Копируем содержимое /home из коммита внутрь varDeployPath
+	if err := copyWithRsync(fmt.Sprintf("%s/home/", ostreeDeployPath), varDeployPath); err != nil {
+		return fmt.Errorf("ошибка копирования /home в @home: %v", err)
+	}
+
+	// Очищаем содержимое /var внутри ostree
+	if err := clearDirectory(fmt.Sprintf("%s/var", ostreeDeployPath)); err != nil {
+		return fmt.Errorf("ошибка очистки содержимого /var: %v", err)
+	}
+
+	return nil
+}
+
+// growMounted монтирует раздел во временную точку и выполняет команду расширения, принимающую точку монтирования
+func growMounted(partition string, mountPoint string, name string, args ...string) error {
+	if err := mountDisk(partition, mountPoint, ""); err != nil {
+		return fmt.Errorf("ошибка монтирования раздела %s: %v", partition, err)
+	}
+	defer unmountOrWarn(mountPoint)
+
+	if err := runCommand(name, append(args, mountPoint)...); err != nil {
+		return fmt.Errorf("ошибка изменения размера файловой системы: %v", err)
+	}
+	return nil
+}
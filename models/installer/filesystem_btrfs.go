@@ -0,0 +1,380 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BtrfsSubvolume подтом btrfs и точка его монтирования в установленной системе
+type BtrfsSubvolume struct {
+	Name       string `json:"name"`        // Имя подтома, например @home
+	MountPoint string `json:"mount_point"` // Точка монтирования, пустая — подтом только создаётся
+	NoCOW      bool   `json:"nodatacow"`   // Отключить copy-on-write (chattr +C) для образов ВМ и баз данных
+}
+
+// BtrfsProfile разметка подтомов и опции монтирования btrfs
+type BtrfsProfile struct {
+	Subvolumes       []BtrfsSubvolume `json:"subvolumes"`
+	Compression      string           `json:"compression"`       // zstd, zlib, lzo или none
+	CompressionLevel int              `json:"compression_level"` // 0 — уровень по умолчанию
+	NoAtime          bool             `json:"noatime"`
+	SSD              bool             `json:"ssd"`
+	Discard          bool             `json:"discard"`
+	FactorySnapshot  bool             `json:"factory_snapshot"` // Снимок только для чтения изменяемых подтомов после установки
+	Snapper          bool             `json:"snapper"`          // Конфигурации snapper для изменяемых подтомов
+}
+
+// defaultBtrfsProfile разметка по умолчанию: @, @home, @var и сжатие zstd:1
+func defaultBtrfsProfile() BtrfsProfile {
+	return BtrfsProfile{
+		Subvolumes: []BtrfsSubvolume{
+			{Name: "@", MountPoint: "/"},
+			{Name: "@home", MountPoint: "/home"},
+			{Name: "@var", MountPoint: "/var"},
+		},
+		Compression:      "zstd",
+		CompressionLevel: 1,
+		FactorySnapshot:  true,
+	}
+}
+
+// loadBtrfsProfile читает профиль btrfs из JSON-файла, незаданные поля берутся по умолчанию
+func loadBtrfsProfile(path string) (BtrfsProfile, error) {
+	profile := defaultBtrfsProfile()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return profile, fmt.Errorf("ошибка чтения профиля btrfs %s: %v", path, err)
+	}
+
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return profile, fmt.Errorf("ошибка разбора профиля btrfs %s: %v", path, err)
+	}
+
+	// Уровень 1 по умолчанию относится к zstd: если алгоритм задан без уровня, используется его уровень по умолчанию
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) == nil {
+		_, hasCompression := fields["compression"]
+		_, hasLevel := fields["compression_level"]
+		if hasCompression && !hasLevel {
+			profile.CompressionLevel = 0
+		}
+	}
+
+	if err := profile.Validate(); err != nil {
+		return profile, fmt.Errorf("некорректный профиль btrfs %s: %v", path, err)
+	}
+
+	return profile, nil
+}
+
+// Validate проверяет, что профиль можно применить к развёртыванию ostree
+func (p BtrfsProfile) Validate() error {
+	names := make(map[string]bool)
+	mountPoints := make(map[string]bool)
+	hasRoot := false
+
+	for _, subVol := range p.Subvolumes {
+		if subVol.Name == "" || strings.Contains(subVol.Name, "/") {
+			return fmt.Errorf("некорректное имя подтома: %q", subVol.Name)
+		}
+		if names[subVol.Name] {
+			return fmt.Errorf("подтом %s указан несколько раз", subVol.Name)
+		}
+		names[subVol.Name] = true
+
+		if subVol.MountPoint == "" {
+			continue
+		}
+
+		mountPoint := filepath.Clean(subVol.MountPoint)
+		if mountPoints[mountPoint] {
+			return fmt.Errorf("точка монтирования %s указана несколько раз", mountPoint)
+		}
+		mountPoints[mountPoint] = true
+
+		// Корень ostree доступен только для чтения, изменяемые данные живут в /var и /home
+		switch {
+		case mountPoint == "/":
+			hasRoot = true
+		case isPathWithin(mountPoint, "/var"), isPathWithin(mountPoint, "/home"):
+		default:
+			return fmt.Errorf("точка монтирования %s подтома %s должна находиться в /var или /home", mountPoint, subVol.Name)
+		}
+	}
+
+	if !hasRoot {
+		return fmt.Errorf("не указан подтом с точкой монтирования /")
+	}
+
+	switch p.Compression {
+	case "none", "":
+	case "lzo":
+		if p.CompressionLevel != 0 {
+			return fmt.Errorf("сжатие lzo не поддерживает уровень")
+		}
+	case "zlib":
+		if p.CompressionLevel < 0 || p.CompressionLevel > 9 {
+			return fmt.Errorf("уровень сжатия zlib должен быть от 1 до 9, 0 — уровень по умолчанию")
+		}
+	case "zstd":
+		if p.CompressionLevel < 0 || p.CompressionLevel > 15 {
+			return fmt.Errorf("уровень сжатия zstd должен быть от 1 до 15, 0 — уровень по умолчанию")
+		}
+	default:
+		return fmt.Errorf("неизвестный алгоритм сжатия: %s", p.Compression)
+	}
+
+	return nil
+}
+
+// CompressionOption возвращает опцию compress= или пустую строку без сжатия
+func (p BtrfsProfile) CompressionOption() string {
+	if p.Compression == "" || p.Compression == "none" {
+		return ""
+	}
+	if p.CompressionLevel > 0 {
+		return fmt.Sprintf("compress=%s:%d", p.Compression, p.CompressionLevel)
+	}
+	return "compress=" + p.Compression
+}
+
+// MountOptions возвращает общие опции монтирования подтомов
+func (p BtrfsProfile) MountOptions() string {
+	options := []string{p.CompressionOption()}
+	if p.NoAtime {
+		options = append(options, "noatime")
+	}
+	if p.SSD {
+		options = append(options, "ssd")
+	}
+	if p.Discard {
+		options = append(options, "discard=async")
+	}
+	return joinMountOptions(options...)
+}
+
+// rootSubvolume возвращает подтом, монтируемый в корень
+func (p BtrfsProfile) rootSubvolume() BtrfsSubvolume {
+	for _, subVol := range p.Subvolumes {
+		if filepath.Clean(subVol.MountPoint) == "/" {
+			return subVol
+		}
+	}
+	return BtrfsSubvolume{Name: "@", MountPoint: "/"}
+}
+
+// addSubvolume добавляет подтом в профиль, если подтома с таким именем ещё нет
+func (p *BtrfsProfile) addSubvolume(subVol BtrfsSubvolume) {
+	for _, existing := range p.Subvolumes {
+		if existing.Name == subVol.Name {
+			return
+		}
+	}
+	p.Subvolumes = append(p.Subvolumes, subVol)
+}
+
+// stateSubvolumes возвращает монтируемые подтома, кроме корня, от родительских к вложенным
+func (p BtrfsProfile) stateSubvolumes() []BtrfsSubvolume {
+	var result []BtrfsSubvolume
+	for _, subVol := range p.Subvolumes {
+		if subVol.MountPoint == "" || filepath.Clean(subVol.MountPoint) == "/" {
+			continue
+		}
+		subVol.MountPoint = filepath.Clean(subVol.MountPoint)
+		result = append(result, subVol)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return strings.Count(result[i].MountPoint, "/") < strings.Count(result[j].MountPoint, "/")
+	})
+	return result
+}
+
+// isPathWithin проверяет, совпадает ли путь с parent или находится внутри него
+func isPathWithin(path string, parent string) bool {
+	return path == parent || strings.HasPrefix(path, parent+"/")
+}
+
+// btrfsDriver root-раздел btrfs с подтомами из профиля
+type btrfsDriver struct {
+	Profile BtrfsProfile
+}
+
+func (d *btrfsDriver) Name() string {
+	return "btrfs"
+}
+
+func (d *btrfsDriver) Description() string {
+	var names []string
+	for _, subVol := range d.Profile.Subvolumes {
+		names = append(names, subVol.Name)
+	}
+	return "Будут добавлены subvolume:" + strings.Join(names, ", ")
+}
+
+func (d *btrfsDriver) Commands() []string {
+	return []string{"mkfs.btrfs", "btrfs", "chattr"}
+}
+
+func (d *btrfsDriver) Format(partition string) error {
+	if err := runCommand("mkfs.btrfs", "-f", partition); err != nil {
+		return fmt.Errorf("ошибка форматирования %s: %v", partition, err)
+	}
+
+	if err := createBtrfsSubVolumes(partition, d.Profile.Subvolumes); err != nil {
+		return fmt.Errorf("ошибка создания подтомов Btrfs: %v", err)
+	}
+	return nil
+}
+
+func (d *btrfsDriver) RootMountOptions() string {
+	return "subvol=" + d.Profile.rootSubvolume().Name
+}
+
+func (d *btrfsDriver) PopulateState(partition string, ostreeDeployPath string) error {
+	subVolumes := d.Profile.stateSubvolumes()
+
+	// Монтируем каждый подтом отдельно: @var -> /mnt/btrfs/var, @home -> /mnt/btrfs/home
+	mountPoints := make(map[string]string)
+	for _, subVol := range subVolumes {
+		mountPoint := btrfsSetupMountPoint(subVol)
+		if err := mountDisk(partition, mountPoint, "subvol="+subVol.Name); err != nil {
+			return fmt.Errorf("ошибка монтирования подтома %s: %v", subVol.Name, err)
+		}
+		defer unmountOrWarn(mountPoint)
+		mountPoints[subVol.Name] = mountPoint
+	}
+
+	for _, subVol := range subVolumes {
+		target := mountPoints[subVol.Name]
+
+		// Вложенные подтома (например, @var-log внутри @var) копируются отдельно,
+		// в родительском остаётся только пустой каталог для точки монтирования
+		var excludes []string
+		for _, nested := range subVolumes {
+			if nested.Name == subVol.Name || !isPathWithin(nested.MountPoint, subVol.MountPoint) {
+				continue
+			}
+			relative := strings.TrimPrefix(nested.MountPoint, subVol.MountPoint)
+			excludes = append(excludes, relative+"/*")
+			if err := os.MkdirAll(filepath.Join(target, relative), 0755); err != nil {
+				return fmt.Errorf("ошибка создания точки монтирования %s: %v", nested.MountPoint, err)
+			}
+		}
+
+		source := filepath.Join(ostreeDeployPath, subVol.MountPoint)
+		if _, err := os.Stat(source); os.IsNotExist(err) {
+			log.Printf("Каталог %s отсутствует в развёртывании, подтом %s останется пустым.\n", subVol.MountPoint, subVol.Name)
+			continue
+		}
+
+		if err := copyWithRsync(source+"/", target, excludes...); err != nil {
+			return fmt.Errorf("ошибка копирования %s в %s: %v", subVol.MountPoint, subVol.Name, err)
+		}
+	}
+
+	//Очищаем содержимое /var внутри ostree
+	if err := clearDirectory(fmt.Sprintf("%s/var", ostreeDeployPath)); err != nil {
+		return fmt.Errorf("ошибка очистки содержимого /var: %v", err)
+	}
+
+	//путь к папке var
+	varDeployPath := fmt.Sprintf("%s/var", filepath.Join(ostreeDeployPath, "../../"))
+
+	//Очищаем содержимое ostree/deploy/default/var
+	if err := clearDirectory(varDeployPath); err != nil {
+		return fmt.Errorf("ошибка очистки содержимого /ostree/deploy/default/var: %v", err)
+	}
+
+	selabeledFilePath := fmt.Sprintf("%s/.ostree-selabeled", varDeployPath)
+	log.Printf("Создание файла %s\n", selabeledFilePath)
+
+	file, err := os.Create(selabeledFilePath)
+	if err != nil {
+		return fmt.Errorf("ошибка создания файла .ostree-selabeled: %v", err)
+	}
+
+	errFile := file.Close()
+	if errFile != nil {
+		return fmt.Errorf("ошибка очистки содержимого /ostree/deploy/default/var: %v", errFile)
+	}
+
+	return nil
+}
+
+func (d *btrfsDriver) CreateSwapFile(partition string, ostreeDeployPath string, sizeMiB int) error {
+	subVol := swapSubvolume()
+	mountPoint := btrfsSetupMountPoint(subVol)
+	if err := mountDisk(partition, mountPoint, "subvol="+subVol.Name); err != nil {
+		return fmt.Errorf("ошибка монтирования подтома %s: %v", subVol.Name, err)
+	}
+	defer unmountOrWarn(mountPoint)
+
+	// mkswapfile сам отключает copy-on-write и сжатие для файла
+	swapFile := filepath.Join(mountPoint, filepath.Base(swapFilePath))
+	if err := runCommand("btrfs", "filesystem", "mkswapfile", "--size", fmt.Sprintf("%dm", sizeMiB), swapFile); err != nil {
+		return fmt.Errorf("ошибка создания файла подкачки: %v", err)
+	}
+	return nil
+}
+
+func (d *btrfsDriver) FstabEntries(uuid string) []string {
+	subVolumes := append([]BtrfsSubvolume{d.Profile.rootSubvolume()}, d.Profile.stateSubvolumes()...)
+	mountOptions := d.Profile.MountOptions()
+
+	var entries []string
+	for _, subVol := range subVolumes {
+		options := joinMountOptions("subvol="+subVol.Name, mountOptions, "x-systemd.device-timeout=0")
+		entries = append(entries, fmt.Sprintf("UUID=%s %s btrfs %s 0 0", uuid, subVol.MountPoint, options))
+	}
+	return entries
+}
+
+func (d *btrfsDriver) Grow(partition string) error {
+	// Для btrfs используем btrfs filesystem resize на точке монтирования
+	log.Printf("Изменение размера файловой системы btrfs на разделе %s...\n", partition)
+	return growMounted(partition, "/mnt/btrfs-root", "btrfs", "filesystem", "resize", "max")
+}
+
+// btrfsSetupMountPoint возвращает временную точку монтирования подтома во время установки
+func btrfsSetupMountPoint(subVol BtrfsSubvolume) string {
+	return filepath.Join("/mnt/btrfs", strings.TrimPrefix(subVol.Name, "@"))
+}
+
+func createBtrfsSubVolumes(rootPartition string, subVolumes []BtrfsSubvolume) (err error) {
+	mountPoint := "/mnt/btrfs-setup"
+	if err := mountDisk(rootPartition, mountPoint, "rw,subvol=/"); err != nil {
+		return fmt.Errorf("ошибка монтирования Btrfs раздела: %v", err)
+	}
+	defer releaseMountPointInto(&err, mountPoint)
+
+	for _, subVol := range subVolumes {
+		subVolPath := fmt.Sprintf("%s/%s", mountPoint, subVol.Name)
+		if _, err := os.Stat(subVolPath); os.IsNotExist(err) {
+			cmd := exec.Command("btrfs", "subvolume", "create", subVolPath)
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			if err := cmd.Run(); err != nil {
+				return fmt.Errorf("ошибка создания подтома %s: %v", subVol.Name, err)
+			}
+		} else {
+			log.Printf("Подтом %s уже существует, пропуск.", subVol.Name)
+		}
+
+		// Атрибут nodatacow наследуется файлами, поэтому выставляется на пустой подтом
+		if subVol.NoCOW {
+			if err := runCommand("chattr", "+C", subVolPath); err != nil {
+				return fmt.Errorf("ошибка отключения copy-on-write для подтома %s: %v", subVol.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
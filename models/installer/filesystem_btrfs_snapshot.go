@@ -0,0 +1,162 @@
+package installer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// snapshotsSubvolume подтом для заводских снимков, в установленной системе не монтируется
+const snapshotsSubvolume = "@snapshots"
+
+// snapperConfigTemplate настройки snapper для подтома с изменяемыми данными
+const snapperConfigTemplate = `# Auto generate from atomic-actions installer
+SUBVOLUME="%s"
+FSTYPE="btrfs"
+ALLOW_GROUPS="wheel"
+SYNC_ACL="yes"
+BACKGROUND_COMPARISON="yes"
+NUMBER_CLEANUP="yes"
+NUMBER_LIMIT="50"
+NUMBER_LIMIT_IMPORTANT="10"
+TIMELINE_CREATE="yes"
+TIMELINE_CLEANUP="yes"
+TIMELINE_LIMIT_HOURLY="5"
+TIMELINE_LIMIT_DAILY="7"
+TIMELINE_LIMIT_WEEKLY="0"
+TIMELINE_LIMIT_MONTHLY="0"
+TIMELINE_LIMIT_YEARLY="0"
+`
+
+// snapshotSubvolumes возвращает подтома с изменяемыми данными, для которых имеет смысл делать снимки.
+// Подтома без copy-on-write (подкачка, контейнеры) пропускаются.
+func (p BtrfsProfile) snapshotSubvolumes() []BtrfsSubvolume {
+	var result []BtrfsSubvolume
+	for _, subVol := range p.stateSubvolumes() {
+		if !subVol.NoCOW {
+			result = append(result, subVol)
+		}
+	}
+	return result
+}
+
+// snapperConfigName возвращает имя конфигурации snapper для подтома: @var-log -> var-log
+func snapperConfigName(subVol BtrfsSubvolume) string {
+	return strings.TrimPrefix(subVol.Name, "@")
+}
+
+// snapperSubvolumePath возвращает реальную точку монтирования подтома: в ostree /home — ссылка на /var/home
+func snapperSubvolumePath(subVol BtrfsSubvolume) string {
+	if isPathWithin(subVol.MountPoint, "/home") {
+		return "/var" + subVol.MountPoint
+	}
+	return subVol.MountPoint
+}
+
+// finalizeSnapshots настраивает snapper и создаёт заводские снимки только для чтения после установки
+func (d *btrfsDriver) finalizeSnapshots(partition string, ostreeDeployPath string) (err error) {
+	if !d.Profile.FactorySnapshot && !d.Profile.Snapper {
+		return nil
+	}
+
+	subVolumes := d.Profile.snapshotSubvolumes()
+	if len(subVolumes) == 0 {
+		return nil
+	}
+
+	mountPoint := "/mnt/btrfs-setup"
+	if err := mountDisk(partition, mountPoint, "rw,subvol=/"); err != nil {
+		return fmt.Errorf("ошибка монтирования Btrfs раздела: %v", err)
+	}
+	defer releaseMountPointInto(&err, mountPoint)
+
+	// Snapper хранит снимки во вложенном подтоме .snapshots, поэтому он создаётся до заводского снимка
+	if d.Profile.Snapper {
+		if err := configureSnapper(mountPoint, ostreeDeployPath, subVolumes); err != nil {
+			return err
+		}
+	}
+
+	if d.Profile.FactorySnapshot {
+		if err := createFactorySnapshots(mountPoint, subVolumes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createFactorySnapshots создаёт снимки @snapshots/factory/<подтом> только для чтения
+func createFactorySnapshots(topLevel string, subVolumes []BtrfsSubvolume) error {
+	snapshotsPath := filepath.Join(topLevel, snapshotsSubvolume)
+	if _, err := os.Stat(snapshotsPath); os.IsNotExist(err) {
+		if err := runCommand("btrfs", "subvolume", "create", snapshotsPath); err != nil {
+			return fmt.Errorf("ошибка создания подтома %s: %v", snapshotsSubvolume, err)
+		}
+	}
+
+	factoryPath := filepath.Join(snapshotsPath, "factory")
+	if err := os.MkdirAll(factoryPath, 0700); err != nil {
+		return fmt.Errorf("ошибка создания каталога %s: %v", factoryPath, err)
+	}
+
+	for _, subVol := range subVolumes {
+		log.Printf("Создание заводского снимка подтома %s...\n", subVol.Name)
+		source := filepath.Join(topLevel, subVol.Name)
+		target := filepath.Join(factoryPath, subVol.Name)
+		if err := runCommand("btrfs", "subvolume", "snapshot", "-r", source, target); err != nil {
+			return fmt.Errorf("ошибка создания снимка подтома %s: %v", subVol.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// configureSnapper создаёт подтома .snapshots и записывает конфигурации snapper в /etc развёртывания
+func configureSnapper(topLevel string, ostreeDeployPath string, subVolumes []BtrfsSubvolume) error {
+	if _, err := os.Stat(filepath.Join(ostreeDeployPath, "usr/bin/snapper")); os.IsNotExist(err) {
+		log.Println("Предупреждение: snapper не найден в образе, конфигурация будет использована после его установки.")
+	}
+
+	configsPath := filepath.Join(ostreeDeployPath, "etc/snapper/configs")
+	if err := os.MkdirAll(configsPath, 0755); err != nil {
+		return fmt.Errorf("ошибка создания каталога %s: %v", configsPath, err)
+	}
+
+	var names []string
+	for _, subVol := range subVolumes {
+		dotSnapshots := filepath.Join(topLevel, subVol.Name, ".snapshots")
+		if _, err := os.Stat(dotSnapshots); os.IsNotExist(err) {
+			if err := runCommand("btrfs", "subvolume", "create", dotSnapshots); err != nil {
+				return fmt.Errorf("ошибка создания подтома .snapshots в %s: %v", subVol.Name, err)
+			}
+		}
+		if err := os.Chmod(dotSnapshots, 0750); err != nil {
+			return fmt.Errorf("ошибка изменения прав %s: %v", dotSnapshots, err)
+		}
+
+		name := snapperConfigName(subVol)
+		configPath := filepath.Join(configsPath, name)
+		content := fmt.Sprintf(snapperConfigTemplate, snapperSubvolumePath(subVol))
+		if err := os.WriteFile(configPath, []byte(content), 0640); err != nil {
+			return fmt.Errorf("ошибка записи %s: %v", configPath, err)
+		}
+
+		log.Printf("Конфигурация snapper %s для %s создана.\n", name, snapperSubvolumePath(subVol))
+		names = append(names, name)
+	}
+
+	sysconfigPath := filepath.Join(ostreeDeployPath, "etc/sysconfig/snapper")
+	if err := os.MkdirAll(filepath.Dir(sysconfigPath), 0755); err != nil {
+		return fmt.Errorf("ошибка создания каталога %s: %v", filepath.Dir(sysconfigPath), err)
+	}
+
+	content := fmt.Sprintf("SNAPPER_CONFIGS=\"%s\"\n", strings.Join(names, " "))
+	if err := os.WriteFile(sysconfigPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("ошибка записи %s: %v", sysconfigPath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBtrfsProfileValidate(t *testing.T) {
+	root := BtrfsSubvolume{Name: "@", MountPoint: "/"}
+	tests := []struct {
+		name    string
+		profile BtrfsProfile
+		wantErr string // Часть текста ошибки, пусто — ошибки нет
+	}{
+		{name: "профиль по умолчанию", profile: defaultBtrfsProfile()},
+		{
+			name: "вложенные точки в /var и подтом без точки монтирования",
+			profile: BtrfsProfile{Subvolumes: []BtrfsSubvolume{
+				root,
+				{Name: "@libvirt", MountPoint: "/var/lib/libvirt", NoCOW: true},
+				{Name: "@snapshots"},
+			}},
+		},
+		{
+			name:    "нет корня",
+			profile: BtrfsProfile{Subvolumes: []BtrfsSubvolume{{Name: "@home", MountPoint: "/home"}}},
+			wantErr: "не указан подтом с точкой монтирования /",
+		},
+		{
+			name:    "пустое имя",
+			profile: BtrfsProfile{Subvolumes: []BtrfsSubvolume{root, {MountPoint: "/home"}}},
+			wantErr: "некорректное имя подтома",
+		},
+		{
+			name:    "слэш в имени",
+			profile: BtrfsProfile{Subvolumes: []BtrfsSubvolume{root, {Name: "@var/log", MountPoint: "/var/log"}}},
+			wantErr: "некорректное имя подтома",
+		},
+		{
+			name:    "повтор имени",
+			profile: BtrfsProfile{Subvolumes: []BtrfsSubvolume{root, {Name: "@"}}},
+			wantErr: "указан несколько раз",
+		},
+		{
+			name:    "повтор точки монтирования после очистки пути",
+			profile: BtrfsProfile{Subvolumes: []BtrfsSubvolume{root, {Name: "@home", MountPoint: "/home"}, {Name: "@h", MountPoint: "/home/"}}},
+			wantErr: "точка монтирования /home указана несколько раз",
+		},
+		{
+			name:    "точка монтирования вне /var и /home",
+			profile: BtrfsProfile{Subvolumes: []BtrfsSubvolume{root, {Name: "@opt", MountPoint: "/opt"}}},
+			wantErr: "должна находиться в /var или /home",
+		},
+		{
+			name:    "похожий на /var путь",
+			profile: BtrfsProfile{Subvolumes: []BtrfsSubvolume{root, {Name: "@v", MountPoint: "/variable"}}},
+			wantErr: "должна находиться в /var или /home",
+		},
+		{name: "без сжатия", profile: BtrfsProfile{Subvolumes: []BtrfsSubvolume{root}, Compression: "none"}},
+		{name: "zstd уровень по умолчанию", profile: BtrfsProfile{Subvolumes: []BtrfsSubvolume{root}, Compression: "zstd"}},
+		{name: "zstd:15", profile: BtrfsProfile{Subvolumes: []BtrfsSubvolume{root}, Compression: "zstd", CompressionLevel: 15}},
+		{
+			name:    "zstd:16",
+			profile: BtrfsProfile{Subvolumes: []BtrfsSubvolume{root}, Compression: "zstd", CompressionLevel: 16},
+			wantErr: "zstd должен быть от 1 до 15",
+		},
+		{name: "zlib:9", profile: BtrfsProfile{Subvolumes: []BtrfsSubvolume{root}, Compression: "zlib", CompressionLevel: 9}},
+		{
+			name:    "zlib:10",
+			profile: BtrfsProfile{Subvolumes: []BtrfsSubvolume{root}, Compression: "zlib", CompressionLevel: 10},
+			wantErr: "zlib должен быть от 1 до 9",
+		},
+		{
+			name:    "отрицательный уровень",
+			profile: BtrfsProfile{Subvolumes: []BtrfsSubvolume{root}, Compression: "zlib", CompressionLevel: -1},
+			wantErr: "zlib должен быть от 1 до 9",
+		},
+		{name: "lzo", profile: BtrfsProfile{Subvolumes: []BtrfsSubvolume{root}, Compression: "lzo"}},
+		{
+			name:    "lzo с уровнем",
+			profile: BtrfsProfile{Subvolumes: []BtrfsSubvolume{root}, Compression: "lzo", CompressionLevel: 1},
+			wantErr: "lzo не поддерживает уровень",
+		},
+		{
+			name:    "неизвестный алгоритм",
+			profile: BtrfsProfile{Subvolumes: []BtrfsSubvolume{root}, Compression: "lz4"},
+			wantErr: "неизвестный алгоритм сжатия",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.profile.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("неожиданная ошибка: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ошибка = %v, ожидалась содержащая %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadBtrfsProfileCompressionLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		level int
+	}{
+		{name: "сжатие не задано: zstd:1 по умолчанию", data: `{}`, level: 1},
+		{name: "алгоритм без уровня: уровень алгоритма по умолчанию", data: `{"compression": "lzo"}`, level: 0},
+		{name: "алгоритм с уровнем", data: `{"compression": "zlib", "compression_level": 6}`, level: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "btrfs.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			profile, err := loadBtrfsProfile(path)
+			if err != nil {
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+			if profile.CompressionLevel != tt.level {
+				t.Errorf("уровень сжатия %d, ожидался %d", profile.CompressionLevel, tt.level)
+			}
+		})
+	}
+}
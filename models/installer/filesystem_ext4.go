@@ -0,0 +1,58 @@
+package installer
+
+import (
+	"fmt"
+	"log"
+)
+
+// ext4Driver root-раздел ext4, установка в корень без подтомов
+type ext4Driver struct{}
+
+func (d *ext4Driver) Name() string {
+	return "ext4"
+}
+
+func (d *ext4Driver) Description() string {
+	return "Установка в корень /"
+}
+
+func (d *ext4Driver) Commands() []string {
+	return []string{"mkfs.ext4", "e2fsck", "resize2fs", "fallocate", "mkswap"}
+}
+
+func (d *ext4Driver) Format(partition string) error {
+	if err := runCommand("mkfs.ext4", partition); err != nil {
+		return fmt.Errorf("ошибка форматирования %s: %v", partition, err)
+	}
+	return nil
+}
+
+func (d *ext4Driver) RootMountOptions() string {
+	return ""
+}
+
+func (d *ext4Driver) PopulateState(partition string, ostreeDeployPath string) error {
+	return populateStateroot(ostreeDeployPath)
+}
+
+func (d *ext4Driver) CreateSwapFile(partition string, ostreeDeployPath string, sizeMiB int) error {
+	return createStaterootSwapFile(ostreeDeployPath, sizeMiB)
+}
+
+func (d *ext4Driver) FstabEntries(uuid string) []string {
+	return []string{fmt.Sprintf("UUID=%s / ext4 defaults 1 1", uuid)}
+}
+
+func (d *ext4Driver) Grow(partition string) error {
+	// Для ext4 используем resize2fs, предварительно проверив файловую систему
+	log.Printf("Проверка и исправление файловой системы ext4 на разделе %s...\n", partition)
+	if err := runCommand("e2fsck", "-f", "-y", partition); err != nil {
+		return fmt.Errorf("ошибка проверки файловой системы ext4: %v", err)
+	}
+
+	log.Printf("Изменение размера файловой системы ext4 на разделе %s...\n", partition)
+	if err := runCommand("resize2fs", partition); err != nil {
+		return fmt.Errorf("ошибка изменения размера файловой системы ext4: %v", err)
+	}
+	return nil
+}
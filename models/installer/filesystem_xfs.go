@@ -0,0 +1,50 @@
+package installer
+
+import (
+	"fmt"
+	"log"
+)
+
+// xfsDriver root-раздел XFS, установка в корень без подтомов.
+// XFS расширяется только в смонтированном состоянии, поэтому Grow монтирует раздел.
+type xfsDriver struct{}
+
+func (d *xfsDriver) Name() string {
+	return "xfs"
+}
+
+func (d *xfsDriver) Description() string {
+	return "Установка в корень /, для серверов"
+}
+
+func (d *xfsDriver) Commands() []string {
+	return []string{"mkfs.xfs", "xfs_growfs", "fallocate", "mkswap"}
+}
+
+func (d *xfsDriver) Format(partition string) error {
+	if err := runCommand("mkfs.xfs", "-f", partition); err != nil {
+		return fmt.Errorf("ошибка форматирования %s: %v", partition, err)
+	}
+	return nil
+}
+
+func (d *xfsDriver) RootMountOptions() string {
+	return ""
+}
+
+func (d *xfsDriver) PopulateState(partition string, ostreeDeployPath string) error {
+	return populateStateroot(ostreeDeployPath)
+}
+
+func (d *xfsDriver) CreateSwapFile(partition string, ostreeDeployPath string, sizeMiB int) error {
+	return createStaterootSwapFile(ostreeDeployPath, sizeMiB)
+}
+
+func (d *xfsDriver) FstabEntries(uuid string) []string {
+	return []string{fmt.Sprintf("UUID=%s / xfs defaults 0 0", uuid)}
+}
+
+func (d *xfsDriver) Grow(partition string) error {
+	log.Printf("Изменение размера файловой системы xfs на разделе %s...\n", partition)
+	return growMounted(partition, "/mnt/xfs-root", "xfs_growfs")
+}
@@ -0,0 +1,292 @@
+package installer
+
+import (
+	"atomic-actions/models/installer/theme"
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// pciVendors производители видеокарт, по которым подбирается образ
+var pciVendors = map[string]string{
+	"0x10de": "nvidia",
+	"0x1002": "amd",
+	"0x8086": "intel",
+}
+
+// cpuFeaturesOfInterest флаги процессора, которые показываются в сводке
+var cpuFeaturesOfInterest = []string{"avx2", "avx512f", "aes", "vmx", "svm", "hypervisor"}
+
+// PCIDevice устройство PCI из /sys/bus/pci/devices
+type PCIDevice struct {
+	Address string // Адрес на шине, например 0000:01:00.0
+	Vendor  string // Идентификатор производителя, например 0x10de
+	Device  string // Идентификатор устройства
+	Class   string // Класс устройства, 0x03xxxx — видеоконтроллеры
+	Driver  string // Загруженный драйвер ядра
+}
+
+// BlockDevice диск из /sys/block
+type BlockDevice struct {
+	Name      string
+	SizeBytes int64
+	Type      string // NVMe, SSD или HDD
+	Removable bool
+}
+
+// HardwareInfo результат опроса оборудования
+type HardwareInfo struct {
+	GPUs        []PCIDevice
+	CpuModel    string
+	CpuCores    int
+	CpuFeatures []string
+	MemoryMiB   int
+	Uefi        bool
+	SecureBoot  SecureBootState
+	Tpm2        bool // Есть TPM 2.0, нужен для разблокировки шифрования через systemd-cryptenroll
+	Battery     bool // Ноутбук: есть батарея, полезна гибернация
+	Disks       []BlockDevice
+}
+
+// probeHardware собирает сведения об оборудовании из sysfs и procfs, недоступные сведения пропускаются
+func probeHardware() HardwareInfo {
+	info := HardwareInfo{
+		GPUs:       readGPUs(),
+		Uefi:       checkUEFISupport(),
+		SecureBoot: readSecureBootState(),
+		Tpm2:       readTpm2Present(),
+		Disks:      readBlockDevices(),
+	}
+	info.CpuModel, info.CpuCores, info.CpuFeatures = readCpuInfo()
+	info.MemoryMiB, _ = getMemoryMiB()
+
+	batteries, _ := filepath.Glob("/sys/class/power_supply/BAT*")
+	info.Battery = len(batteries) > 0
+	return info
+}
+
+// readGPUs возвращает видеоконтроллеры с шины PCI
+func readGPUs() []PCIDevice {
+	var gpus []PCIDevice
+	devices, _ := filepath.Glob("/sys/bus/pci/devices/*")
+	for _, path := range devices {
+		device := PCIDevice{
+			Address: filepath.Base(path),
+			Vendor:  readSysfsValue(filepath.Join(path, "vendor")),
+			Device:  readSysfsValue(filepath.Join(path, "device")),
+			Class:   readSysfsValue(filepath.Join(path, "class")),
+		}
+		if !strings.HasPrefix(device.Class, "0x03") {
+			continue
+		}
+		if driver, err := os.Readlink(filepath.Join(path, "driver")); err == nil {
+			device.Driver = filepath.Base(driver)
+		}
+		gpus = append(gpus, device)
+	}
+	return gpus
+}
+
+// readCpuInfo возвращает модель процессора, число логических ядер и интересующие флаги из /proc/cpuinfo
+func readCpuInfo() (string, int, []string) {
+	file, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return "", 0, nil
+	}
+	defer file.Close()
+
+	model, cores := "", 0
+	flags := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "processor":
+			cores++
+		case "model name":
+			model = strings.TrimSpace(value)
+		case "flags":
+			for _, flag := range strings.Fields(value) {
+				flags[flag] = true
+			}
+		}
+	}
+
+	var features []string
+	for _, feature := range cpuFeaturesOfInterest {
+		if flags[feature] {
+			features = append(features, feature)
+		}
+	}
+	return model, cores, features
+}
+
+// readTpm2Present проверяет наличие TPM 2.0
+func readTpm2Present() bool {
+	return readSysfsValue("/sys/class/tpm/tpm0/tpm_version_major") == "2"
+}
+
+// readBlockDevices возвращает физические диски, zram, loop и device-mapper пропускаются
+func readBlockDevices() []BlockDevice {
+	var disks []BlockDevice
+	devices, _ := filepath.Glob("/sys/block/*")
+	for _, path := range devices {
+		name := filepath.Base(path)
+		if strings.HasPrefix(name, "zram") || strings.HasPrefix(name, "loop") ||
+			strings.HasPrefix(name, "dm-") || strings.HasPrefix(name, "sr") {
+			continue
+		}
+
+		sectors, _ := strconv.ParseInt(readSysfsValue(filepath.Join(path, "size")), 10, 64)
+		disk := BlockDevice{
+			Name:      name,
+			SizeBytes: sectors * 512, // Размер в sysfs всегда в 512-байтных секторах
+			Type:      "SSD",
+			Removable: readSysfsValue(filepath.Join(path, "removable")) == "1",
+		}
+		switch {
+		case strings.HasPrefix(name, "nvme"):
+			disk.Type = "NVMe"
+		case readSysfsValue(filepath.Join(path, "queue/rotational")) == "1":
+			disk.Type = "HDD"
+		}
+		disks = append(disks, disk)
+	}
+	return disks
+}
+
+// readSysfsValue читает однострочное значение из sysfs, при ошибке возвращает пустую строку
+func readSysfsValue(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Tags возвращает метки оборудования для подбора образа из каталога: производители видеокарт
+func (h HardwareInfo) Tags() []string {
+	var tags []string
+	for _, gpu := range h.GPUs {
+		if tag, ok := pciVendors[gpu.Vendor]; ok {
+			tags = append(tags, tag)
+		}
+	}
+	// Дискретная видеокарта NVIDIA важнее встроенной, её метка проверяется первой
+	for i, tag := range tags {
+		if tag == "nvidia" {
+			tags[0], tags[i] = tags[i], tags[0]
+		}
+	}
+	return tags
+}
+
+// RecommendedBootMode возвращает тип загрузки под прошивку компьютера
+func (h HardwareInfo) RecommendedBootMode() string {
+	if h.Uefi {
+		return "UEFI"
+	}
+	return "LEGACY"
+}
+
+// RecommendedSwap возвращает подкачку под оборудование: на ноутбуке раздел с гибернацией, иначе zram.
+// Раздел не больше availableMiB — места, которое останется на диске после разметки. Если раздел под гибернацию
+// не помещается, предлагается раздел поменьше без гибернации, а если места нет совсем — zram.
+func (h HardwareInfo) RecommendedSwap(availableMiB int) SwapConfig {
+	if !h.Battery || h.MemoryMiB <= 0 {
+		return SwapConfig{Mode: "zram"}
+	}
+
+	if size := recommendedSwapMiB(h.MemoryMiB, true); size <= availableMiB {
+		return SwapConfig{Mode: "partition", SizeMiB: size, Hibernate: true}
+	}
+	if size := min(recommendedSwapMiB(h.MemoryMiB, false), availableMiB); size >= minSwapPartitionMiB {
+		return SwapConfig{Mode: "partition", SizeMiB: size}
+	}
+	return SwapConfig{Mode: "zram"}
+}
+
+// largestDiskMiB возвращает размер самого большого несъёмного диска в МиБ, 0 — диски не найдены
+func (h HardwareInfo) largestDiskMiB() int {
+	largest := 0
+	for _, disk := range h.Disks {
+		if !disk.Removable {
+			largest = max(largest, int(disk.SizeBytes>>20))
+		}
+	}
+	return largest
+}
+
+// TpmEncryptionAvailable сообщает, можно ли разблокировать зашифрованный диск через TPM: нужны TPM 2.0 и UEFI
+func (h HardwareInfo) TpmEncryptionAvailable() bool {
+	return h.Tpm2 && h.Uefi
+}
+
+// Describe возвращает сводку об оборудовании в виде строк
+func (h HardwareInfo) Describe() []string {
+	firmware := "BIOS"
+	if h.Uefi {
+		firmware = "UEFI, " + h.SecureBoot.Describe()
+	}
+
+	lines := []string{
+		fmt.Sprintf("Процессор: %s (%d потоков) %s", h.CpuModel, h.CpuCores, strings.Join(h.CpuFeatures, " ")),
+		fmt.Sprintf("Оперативная память: %.1f ГБ", float64(h.MemoryMiB)/1024),
+		"Прошивка: " + firmware,
+	}
+
+	for _, gpu := range h.GPUs {
+		vendor, ok := pciVendors[gpu.Vendor]
+		if !ok {
+			vendor = gpu.Vendor
+		}
+		line := fmt.Sprintf("Видеокарта: %s %s:%s", vendor, gpu.Vendor, gpu.Device)
+		if gpu.Driver != "" {
+			line += ", драйвер " + gpu.Driver
+		}
+		lines = append(lines, line)
+	}
+
+	for _, disk := range h.Disks {
+		line := fmt.Sprintf("Диск: /dev/%s %s %s", disk.Name, disk.Type, formatBytes(disk.SizeBytes))
+		if disk.Removable {
+			line += ", съёмный"
+		}
+		lines = append(lines, line)
+	}
+
+	tpm := "нет"
+	if h.Tpm2 {
+		tpm = "TPM 2.0"
+	}
+	lines = append(lines, "TPM: "+tpm)
+	return lines
+}
+
+// printHardwareSummary выводит сводку об оборудовании и рекомендации перед началом установки
+func printHardwareSummary(h HardwareInfo, recommendedImage string) {
+	fmt.Println(theme.HeaderStyle.Render("Оборудование:"))
+	for _, line := range h.Describe() {
+		fmt.Println(" " + line)
+	}
+
+	fmt.Println(theme.HeaderStyle.Render("Рекомендации:"))
+	if recommendedImage != "" {
+		fmt.Println(" Образ: " + recommendedImage)
+	}
+	fmt.Println(" Тип загрузки: " + h.RecommendedBootMode())
+	// Диск ещё не выбран, поэтому подкачка оценивается по самому большому диску
+	fmt.Println(" Подкачка: " + describeSwap(h.RecommendedSwap(swapSpaceMiB(h.largestDiskMiB()))))
+	if h.TpmEncryptionAvailable() {
+		fmt.Println(theme.SuccessStyle.Render(" Шифрование диска с разблокировкой через TPM доступно."))
+	} else {
+		fmt.Println(theme.WarningsStyle.Render(" Шифрование диска с разблокировкой через TPM недоступно: нужны TPM 2.0 и UEFI."))
+	}
+	fmt.Println()
+}
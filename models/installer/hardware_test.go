@@ -0,0 +1,99 @@
+package installer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHardwareInfoTags(t *testing.T) {
+	tests := []struct {
+		name string
+		gpus []PCIDevice
+		want []string
+	}{
+		{name: "нет видеокарт", gpus: nil, want: nil},
+		{name: "встроенная intel", gpus: []PCIDevice{{Vendor: "0x8086"}}, want: []string{"intel"}},
+		{name: "неизвестный производитель", gpus: []PCIDevice{{Vendor: "0x1234"}}, want: nil},
+		{
+			name: "дискретная nvidia первой",
+			gpus: []PCIDevice{{Vendor: "0x8086"}, {Vendor: "0x10de"}},
+			want: []string{"nvidia", "intel"},
+		},
+		{
+			name: "amd и nvidia",
+			gpus: []PCIDevice{{Vendor: "0x1002"}, {Vendor: "0x8086"}, {Vendor: "0x10de"}},
+			want: []string{"nvidia", "intel", "amd"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (HardwareInfo{GPUs: tt.gpus}).Tags(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tags() = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHardwareInfoRecommendedSwap(t *testing.T) {
+	tests := []struct {
+		name      string
+		hardware  HardwareInfo
+		available int
+		want      SwapConfig
+	}{
+		{name: "компьютер без батареи", hardware: HardwareInfo{MemoryMiB: 16384}, available: 102400, want: SwapConfig{Mode: "zram"}},
+		{
+			name:      "ноутбук: раздел с гибернацией",
+			hardware:  HardwareInfo{MemoryMiB: 16384, Battery: true},
+			available: 102400,
+			want:      SwapConfig{Mode: "partition", SizeMiB: 20480, Hibernate: true},
+		},
+		{
+			name:      "ноутбук: места под гибернацию не хватает",
+			hardware:  HardwareInfo{MemoryMiB: 16384, Battery: true},
+			available: 10240,
+			want:      SwapConfig{Mode: "partition", SizeMiB: 8192},
+		},
+		{
+			name:      "ноутбук: раздел урезан до свободного места",
+			hardware:  HardwareInfo{MemoryMiB: 16384, Battery: true},
+			available: 4096,
+			want:      SwapConfig{Mode: "partition", SizeMiB: 4096},
+		},
+		{name: "ноутбук: места нет", hardware: HardwareInfo{MemoryMiB: 16384, Battery: true}, available: 512, want: SwapConfig{Mode: "zram"}},
+		{name: "ноутбук, объём памяти неизвестен", hardware: HardwareInfo{Battery: true}, available: 102400, want: SwapConfig{Mode: "zram"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hardware.RecommendedSwap(tt.available); got != tt.want {
+				t.Errorf("RecommendedSwap() = %+v, ожидалось %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHardwareInfoRecommendations(t *testing.T) {
+	tests := []struct {
+		name     string
+		hardware HardwareInfo
+		bootMode string
+		tpm      bool
+	}{
+		{name: "BIOS", hardware: HardwareInfo{Tpm2: true}, bootMode: "LEGACY", tpm: false},
+		{name: "UEFI без TPM", hardware: HardwareInfo{Uefi: true}, bootMode: "UEFI", tpm: false},
+		{name: "UEFI с TPM", hardware: HardwareInfo{Uefi: true, Tpm2: true}, bootMode: "UEFI", tpm: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hardware.RecommendedBootMode(); got != tt.bootMode {
+				t.Errorf("RecommendedBootMode() = %q, ожидалось %q", got, tt.bootMode)
+			}
+			if got := tt.hardware.TpmEncryptionAvailable(); got != tt.tpm {
+				t.Errorf("TpmEncryptionAvailable() = %t, ожидалось %t", got, tt.tpm)
+			}
+		})
+	}
+}
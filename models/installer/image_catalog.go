@@ -0,0 +1,153 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Каталог образов: системный файл и пользовательский, записи пользователя заменяют системные с той же ссылкой
+const (
+	systemCatalogPath = "/usr/local/share/atomic-actions/images.json"
+	userCatalogPath   = ".local/share/atomic-actions/images.json"
+)
+
+// CatalogImage образ из каталога
+type CatalogImage struct {
+	Name                  string   `json:"name"`
+	Reference             string   `json:"reference"`
+	Description           string   `json:"description"`
+	Hardware              []string `json:"hardware"`               // Оборудование, для которого предназначен образ, например nvidia
+	MinDiskGb             int      `json:"min_disk_gb"`            // Минимальный размер диска
+	RecommendedFilesystem string   `json:"recommended_filesystem"` // Файловая система, выбираемая по умолчанию
+	Default               bool     `json:"default"`                // Образ по умолчанию, если оборудование не требует другого
+	UnsignedModules       bool     `json:"unsigned_modules"`       // Модули ядра без подписи дистрибутива, нужен MOK при Secure Boot
+}
+
+// builtinCatalog используется, если файлы каталога не найдены
+var builtinCatalog = []CatalogImage{
+	{
+		Name:                  "Alt Atomic",
+		Reference:             "ghcr.io/alt-gnome/alt-atomic:latest",
+		Description:           "Базовый образ",
+		MinDiskGb:             60,
+		RecommendedFilesystem: "btrfs",
+		Default:               true,
+	},
+	{
+		Name:                  "Alt Atomic NVIDIA",
+		Reference:             "ghcr.io/alt-gnome/alt-atomic:latest-nv",
+		Description:           "Для NVIDIA-видеокарт, проприетарный драйвер",
+		Hardware:              []string{"nvidia"},
+		MinDiskGb:             60,
+		RecommendedFilesystem: "btrfs",
+		UnsignedModules:       true,
+	},
+}
+
+// loadImageCatalog читает системный и пользовательский каталоги образов
+func loadImageCatalog() []CatalogImage {
+	catalog, err := readCatalogFile(systemCatalogPath)
+	if err != nil {
+		log.Println(err)
+	}
+
+	userCatalog, err := readCatalogFile(filepath.Join(os.Getenv("HOME"), userCatalogPath))
+	if err != nil {
+		log.Println(err)
+	}
+
+	catalog = mergeCatalogs(catalog, userCatalog)
+	if len(catalog) == 0 {
+		return builtinCatalog
+	}
+	return catalog
+}
+
+// readCatalogFile читает файл каталога, отсутствие файла ошибкой не считается
+func readCatalogFile(path string) ([]CatalogImage, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения каталога образов %s: %v", path, err)
+	}
+
+	var catalog []CatalogImage
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("ошибка разбора каталога образов %s: %v", path, err)
+	}
+
+	for i, image := range catalog {
+		if image.Reference == "" {
+			return nil, fmt.Errorf("в каталоге образов %s у записи %d не указано поле reference", path, i+1)
+		}
+		if image.RecommendedFilesystem != "" {
+			if _, err := getFilesystemDriver(image.RecommendedFilesystem); err != nil {
+				return nil, fmt.Errorf("каталог образов %s, %s: %v", path, image.Reference, err)
+			}
+		}
+	}
+	return catalog, nil
+}
+
+// mergeCatalogs добавляет записи override к base, совпадающие по ссылке записи заменяются
+func mergeCatalogs(base []CatalogImage, override []CatalogImage) []CatalogImage {
+	merged := append([]CatalogImage{}, base...)
+	for _, image := range override {
+		replaced := false
+		for i := range merged {
+			if merged[i].Reference == image.Reference {
+				merged[i] = image
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged = append(merged, image)
+		}
+	}
+	return merged
+}
+
+// findCatalogImage возвращает запись каталога по ссылке на образ
+func findCatalogImage(catalog []CatalogImage, reference string) *CatalogImage {
+	for i := range catalog {
+		if catalog[i].Reference == reference {
+			return &catalog[i]
+		}
+	}
+	return nil
+}
+
+// recommendedCatalogImage выбирает образ под обнаруженное оборудование, иначе образ по умолчанию.
+// Теги оборудования перебираются в порядке приоритета, поэтому nvidia важнее прочих видеокарт
+func recommendedCatalogImage(catalog []CatalogImage, hardware []string) string {
+	for _, detected := range hardware {
+		for _, image := range catalog {
+			for _, required := range image.Hardware {
+				if strings.EqualFold(required, detected) {
+					return image.Reference
+				}
+			}
+		}
+	}
+
+	for _, image := range catalog {
+		if image.Default {
+			return image.Reference
+		}
+	}
+	return ""
+}
+
+// checkCatalogDiskSize проверяет, что диск не меньше минимального размера, указанного в каталоге для образа
+func checkCatalogDiskSize(entry *CatalogImage, sizeGb float64) error {
+	if entry == nil || entry.MinDiskGb == 0 || sizeGb >= float64(entry.MinDiskGb) {
+		return nil
+	}
+	return fmt.Errorf("образу %s требуется диск не меньше %d ГБ, доступно %.0f ГБ", entry.Reference, entry.MinDiskGb, sizeGb)
+}
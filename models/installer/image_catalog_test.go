@@ -0,0 +1,82 @@
+package installer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeCatalogs(t *testing.T) {
+	base := []CatalogImage{
+		{Name: "Base", Reference: "ghcr.io/a:latest", Default: true},
+		{Name: "NV", Reference: "ghcr.io/a:latest-nv", Hardware: []string{"nvidia"}},
+	}
+
+	tests := []struct {
+		name     string
+		override []CatalogImage
+		want     []CatalogImage
+	}{
+		{name: "без изменений", override: nil, want: base},
+		{
+			name:     "запись заменяется по ссылке целиком",
+			override: []CatalogImage{{Name: "Свой NV", Reference: "ghcr.io/a:latest-nv", MinDiskGb: 80}},
+			want: []CatalogImage{
+				{Name: "Base", Reference: "ghcr.io/a:latest", Default: true},
+				{Name: "Свой NV", Reference: "ghcr.io/a:latest-nv", MinDiskGb: 80},
+			},
+		},
+		{
+			name:     "новая запись добавляется в конец",
+			override: []CatalogImage{{Name: "Dev", Reference: "registry.local/a:dev"}},
+			want: []CatalogImage{
+				{Name: "Base", Reference: "ghcr.io/a:latest", Default: true},
+				{Name: "NV", Reference: "ghcr.io/a:latest-nv", Hardware: []string{"nvidia"}},
+				{Name: "Dev", Reference: "registry.local/a:dev"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeCatalogs(base, tt.override); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeCatalogs() = %+v, ожидалось %+v", got, tt.want)
+			}
+		})
+	}
+
+	// Исходный каталог не должен меняться при замене записей
+	if base[1].Name != "NV" {
+		t.Errorf("mergeCatalogs изменил исходный каталог: %+v", base)
+	}
+}
+
+func TestRecommendedCatalogImage(t *testing.T) {
+	catalog := []CatalogImage{
+		{Reference: "ghcr.io/a:latest", Default: true},
+		{Reference: "ghcr.io/a:latest-nv", Hardware: []string{"nvidia"}},
+		{Reference: "ghcr.io/a:latest-amd", Hardware: []string{"AMD"}},
+	}
+
+	tests := []struct {
+		name     string
+		catalog  []CatalogImage
+		hardware []string
+		want     string
+	}{
+		{name: "оборудование не требует другого образа", catalog: catalog, hardware: nil, want: "ghcr.io/a:latest"},
+		{name: "nvidia", catalog: catalog, hardware: []string{"intel", "nvidia"}, want: "ghcr.io/a:latest-nv"},
+		{name: "регистр не важен", catalog: catalog, hardware: []string{"amd"}, want: "ghcr.io/a:latest-amd"},
+		{name: "порядок тегов важнее порядка каталога", catalog: catalog, hardware: []string{"amd", "nvidia"}, want: "ghcr.io/a:latest-amd"},
+		{name: "nvidia первой в тегах", catalog: catalog, hardware: []string{"nvidia", "amd"}, want: "ghcr.io/a:latest-nv"},
+		{name: "нет образа по умолчанию", catalog: catalog[1:], hardware: []string{"intel"}, want: ""},
+		{name: "пустой каталог", catalog: nil, hardware: []string{"nvidia"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recommendedCatalogImage(tt.catalog, tt.hardware); got != tt.want {
+				t.Errorf("recommendedCatalogImage(%v) = %q, ожидалось %q", tt.hardware, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// imageDetailLabels метки OCI, которые показываются на шаге выбора образа
+var imageDetailLabels = []string{
+	"org.opencontainers.image.title",
+	"org.opencontainers.image.version",
+	"org.opencontainers.image.description",
+	"org.opencontainers.image.revision",
+}
+
+// ImageDetails сведения об образе из skopeo inspect и локального хранилища podman
+type ImageDetails struct {
+	Digest           string
+	Created          time.Time
+	Architecture     string
+	Os               string
+	CompressedSize   int64 // Сумма сжатых слоёв в реестре
+	UncompressedSize int64 // Размер распакованного образа, известен только для загруженных образов
+	Labels           map[string]string
+}
+
+// skopeoInspect часть вывода skopeo inspect
+type skopeoInspect struct {
+	Digest       string            `json:"Digest"`
+	Created      time.Time         `json:"Created"`
+	Architecture string            `json:"Architecture"`
+	Os           string            `json:"Os"`
+	Labels       map[string]string `json:"Labels"`
+	LayersData   []imageLayer      `json:"LayersData"`
+}
+
+// imageLayer слой образа из skopeo inspect
+type imageLayer struct {
+	Digest string `json:"Digest"`
+	Size   int64  `json:"Size"`
+}
+
+// podmanImageInspect часть вывода podman image inspect
+type podmanImageInspect struct {
+	Size int64 `json:"Size"`
+}
+
+// getImageDetails запрашивает сведения об образе, для образов из реестра требуется сеть
+func getImageDetails(image string) (*ImageDetails, error) {
+	output, err := skopeoCommand("inspect", skopeoReference(image)).Output()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения сведений об образе %s: %v", image, err)
+	}
+
+	var inspect skopeoInspect
+	if err := json.Unmarshal(output, &inspect); err != nil {
+		return nil, fmt.Errorf("ошибка разбора сведений об образе: %v", err)
+	}
+
+	details := &ImageDetails{
+		Digest:       inspect.Digest,
+		Created:      inspect.Created,
+		Architecture: inspect.Architecture,
+		Os:           inspect.Os,
+		Labels:       inspect.Labels,
+	}
+	for _, layer := range inspect.LayersData {
+		details.CompressedSize += layer.Size
+	}
+
+	// Распакованный размер есть только у образа, уже загруженного в хранилище хоста
+	if !isLocalImage(image) {
+		if output, err := exec.Command("podman", "image", "inspect", image).Output(); err == nil {
+			var local []podmanImageInspect
+			if json.Unmarshal(output, &local) == nil && len(local) > 0 {
+				details.UncompressedSize = local[0].Size
+			}
+		}
+	}
+
+	return details, nil
+}
+
+// ArchMismatch сообщает, что образ собран под другую архитектуру, чем текущая машина
+func (d *ImageDetails) ArchMismatch() bool {
+	return d.Architecture != "" && d.Architecture != runtime.GOARCH
+}
+
+// Describe возвращает сведения об образе в виде строк для шага выбора образа
+func (d *ImageDetails) Describe() []string {
+	lines := []string{
+		"Дайджест: " + d.Digest,
+		fmt.Sprintf("Архитектура: %s/%s", d.Os, d.Architecture),
+	}
+	if !d.Created.IsZero() {
+		lines = append(lines, "Создан: "+d.Created.Local().Format("2006-01-02 15:04"))
+	}
+
+	size := "Размер: " + formatBytes(d.CompressedSize) + " (сжатый)"
+	if d.UncompressedSize > 0 {
+		size += ", " + formatBytes(d.UncompressedSize) + " (распакованный)"
+	}
+	lines = append(lines, size)
+
+	var labels []string
+	for _, key := range imageDetailLabels {
+		if value, ok := d.Labels[key]; ok && value != "" {
+			labels = append(labels, fmt.Sprintf("%s: %s", strings.TrimPrefix(key, "org.opencontainers.image."), value))
+		}
+	}
+	sort.Strings(labels)
+	return append(lines, labels...)
+}
+
+// formatBytes форматирует размер в МБ или ГБ
+func formatBytes(size int64) string {
+	if size >= 1<<30 {
+		return fmt.Sprintf("%.1f ГБ", float64(size)/(1<<30))
+	}
+	return fmt.Sprintf("%.0f МБ", float64(size)/(1<<20))
+}
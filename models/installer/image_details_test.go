@@ -0,0 +1,100 @@
+package installer
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0 МБ"},
+		{512 << 20, "512 МБ"},
+		{1<<30 - 1, "1024 МБ"},
+		{1 << 30, "1.0 ГБ"},
+		{3<<30 + 1<<29, "3.5 ГБ"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := formatBytes(tt.size); got != tt.want {
+				t.Errorf("formatBytes(%d) = %q, ожидалось %q", tt.size, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageDetailsArchMismatch(t *testing.T) {
+	tests := []struct {
+		architecture string
+		want         bool
+	}{
+		{"", false},
+		{runtime.GOARCH, false},
+		{"riscv-none", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.architecture, func(t *testing.T) {
+			details := &ImageDetails{Architecture: tt.architecture}
+			if got := details.ArchMismatch(); got != tt.want {
+				t.Errorf("ArchMismatch() для %q = %t, ожидалось %t", tt.architecture, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageDetailsDescribe(t *testing.T) {
+	tests := []struct {
+		name    string
+		details ImageDetails
+		want    []string
+	}{
+		{
+			name:    "образ из реестра без меток",
+			details: ImageDetails{Digest: "sha256:abc", Architecture: "amd64", Os: "linux", CompressedSize: 2 << 30},
+			want: []string{
+				"Дайджест: sha256:abc",
+				"Архитектура: linux/amd64",
+				"Размер: 2.0 ГБ (сжатый)",
+			},
+		},
+		{
+			name: "загруженный образ с метками",
+			details: ImageDetails{
+				Digest:           "sha256:abc",
+				Created:          time.Date(2026, 3, 14, 15, 9, 0, 0, time.Local),
+				Architecture:     "amd64",
+				Os:               "linux",
+				CompressedSize:   900 << 20,
+				UncompressedSize: 3 << 30,
+				Labels: map[string]string{
+					"org.opencontainers.image.version":  "20260314",
+					"org.opencontainers.image.title":    "Onyx",
+					"org.opencontainers.image.url":      "https://example.org",
+					"org.opencontainers.image.revision": "",
+				},
+			},
+			want: []string{
+				"Дайджест: sha256:abc",
+				"Архитектура: linux/amd64",
+				"Создан: 2026-03-14 15:09",
+				"Размер: 900 МБ (сжатый), 3.0 ГБ (распакованный)",
+				"title: Onyx",
+				"version: 20260314",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.details.Describe(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Describe() = %q, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+}
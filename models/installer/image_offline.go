@@ -0,0 +1,289 @@
+package installer
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// localTransports источники образов, не требующие сети
+var localTransports = []string{"oci-archive:", "oci:", "docker-archive:", "containers-storage:"}
+
+// offlineImageName имя, под которым локальный образ загружается во временное хранилище установки
+const offlineImageName = "localhost/atomic-actions-install:latest"
+
+// hostStoragePath точка, куда подключается хранилище контейнеров хоста, пока /var/lib/containers занят временным разделом
+const hostStoragePath = "/mnt/host-containers"
+
+// offlineMountRoots каталоги, в которых монтируются USB-накопители
+var offlineMountRoots = []string{"/run/media", "/media", "/mnt"}
+
+// offlineScanDepth глубина поиска архивов: /run/media/<пользователь>/<метка>/<каталог>/<архив>
+const offlineScanDepth = 4
+
+// isLocalImage сообщает, указан ли образ с локальным транспортом
+func isLocalImage(image string) bool {
+	for _, transport := range localTransports {
+		if strings.HasPrefix(image, transport) {
+			return true
+		}
+	}
+	return false
+}
+
+// skopeoReference возвращает ссылку для skopeo, по умолчанию образ берётся из реестра
+func skopeoReference(image string) string {
+	if isLocalImage(image) {
+		return image
+	}
+	return "docker://" + image
+}
+
+// scanOfflineImages ищет OCI и docker архивы и OCI-каталоги на подключённых накопителях
+func scanOfflineImages() []Choice {
+	var images []Choice
+	for _, root := range offlineMountRoots {
+		rootDepth := strings.Count(root, string(os.PathSeparator))
+		_ = filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			// Во время установки в /mnt монтируются разделы целевого диска
+			if path == "/mnt/target" || path == hostStoragePath {
+				return filepath.SkipDir
+			}
+			if entry.IsDir() {
+				if strings.Count(path, string(os.PathSeparator))-rootDepth > offlineScanDepth {
+					return filepath.SkipDir
+				}
+				if _, err := os.Stat(filepath.Join(path, "oci-layout")); err == nil {
+					images = append(images, Choice{Name: "oci:" + path, Description: "OCI-каталог на накопителе"})
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if strings.HasSuffix(path, ".tar") {
+				if transport := archiveTransport(path); transport != "" {
+					images = append(images, Choice{Name: transport + path, Description: "Архив образа на накопителе"})
+				}
+			}
+			return nil
+		})
+	}
+	return images
+}
+
+// archiveTransport определяет формат архива: в OCI-архиве есть oci-layout, в docker save — manifest.json
+func archiveTransport(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	transport := ""
+	reader := tar.NewReader(file)
+	for {
+		header, err := reader.Next()
+		if err != nil {
+			break
+		}
+		switch strings.TrimPrefix(header.Name, "./") {
+		case "oci-layout":
+			return "oci-archive:"
+		case "manifest.json":
+			transport = "docker-archive:"
+		}
+	}
+	return transport
+}
+
+// bindHostStorage подключает хранилище контейнеров хоста в hostStoragePath до монтирования временного раздела
+func bindHostStorage() error {
+	if err := os.MkdirAll(hostStoragePath, 0755); err != nil {
+		return fmt.Errorf("ошибка создания каталога %s: %v", hostStoragePath, err)
+	}
+	if err := runCommand("mount", "--bind", container_dir, hostStoragePath); err != nil {
+		return fmt.Errorf("ошибка подключения хранилища контейнеров хоста: %v", err)
+	}
+	return nil
+}
+
+// loadOfflineImage копирует локальный образ во временное хранилище установки без обращения к сети
+func loadOfflineImage(image string) error {
+	source := image
+	if strings.HasPrefix(image, "containers-storage:") {
+		source = fmt.Sprintf("containers-storage:[%s/storage]%s", hostStoragePath, strings.TrimPrefix(image, "containers-storage:"))
+	}
+
+	log.Printf("Загрузка образа %s во временное хранилище...\n", image)
+	cmd := exec.Command("skopeo", "copy", source, "containers-storage:"+offlineImageName)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ошибка загрузки образа %s: %v", image, err)
+	}
+	return nil
+}
+
+// OCI-аннотации с именем образа: containerd и podman пишут полное имя, ref.name часто содержит только тег
+const (
+	annotationImageName = "io.containerd.image.name"
+	annotationRefName   = "org.opencontainers.image.ref.name"
+)
+
+// ociIndex index.json OCI-каталога или OCI-архива
+type ociIndex struct {
+	Manifests []struct {
+		Annotations map[string]string `json:"annotations"`
+	} `json:"manifests"`
+}
+
+// dockerArchiveManifest manifest.json архива docker save
+type dockerArchiveManifest []struct {
+	RepoTags []string `json:"RepoTags"`
+}
+
+// targetImgref возвращает образ в реестре, из которого установленная система будет обновляться.
+// Локальный образ загружается под именем offlineImageName, поэтому источник обновлений берётся из
+// --target-imgref, а если он не задан — из имени образа, сохранённого в архиве
+func targetImgref(image string, override string) (string, error) {
+	if override != "" {
+		return override, nil
+	}
+	if !isLocalImage(image) {
+		return image, nil
+	}
+
+	if name, ok := strings.CutPrefix(image, "containers-storage:"); ok {
+		if !strings.HasPrefix(name, "localhost/") {
+			return name, nil
+		}
+	} else if name, err := archiveImageName(image); err != nil {
+		return "", err
+	} else if name != "" {
+		return name, nil
+	}
+	return "", fmt.Errorf("в образе %s не указан реестр для обновлений, задайте его через --target-imgref", image)
+}
+
+// archiveImageName читает имя образа из oci-archive, oci или docker-archive, пусто — имя в архиве не сохранено
+func archiveImageName(image string) (string, error) {
+	transport, rest, _ := strings.Cut(image, ":")
+	path, selector := splitArchivePath(rest)
+
+	var data []byte
+	var err error
+	switch transport {
+	case "oci":
+		data, err = os.ReadFile(filepath.Join(path, "index.json"))
+	case "oci-archive":
+		data, err = readArchiveFile(path, "index.json")
+	case "docker-archive":
+		data, err = readArchiveFile(path, "manifest.json")
+	}
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения образа %s: %v", image, err)
+	}
+
+	var names []string
+	if transport == "docker-archive" {
+		// В docker-archive после пути указывается имя образа или номер вида @0
+		names, err = dockerArchiveImageNames(data)
+		names = append([]string{selector}, names...)
+	} else {
+		names, err = ociImageNames(data, selector)
+	}
+	if err != nil {
+		return "", fmt.Errorf("ошибка разбора образа %s: %v", image, err)
+	}
+
+	for _, name := range names {
+		// Имя без реестра, например только тег, не подходит для обновлений
+		if strings.Contains(name, "/") {
+			return name, nil
+		}
+	}
+	return "", nil
+}
+
+// splitArchivePath отделяет путь к архиву от ссылки на образ внутри него (oci-archive:путь:тег)
+func splitArchivePath(value string) (string, string) {
+	if _, err := os.Stat(value); err == nil {
+		return value, ""
+	}
+	for i := range len(value) {
+		if value[i] != ':' {
+			continue
+		}
+		if _, err := os.Stat(value[:i]); err == nil {
+			return value[:i], value[i+1:]
+		}
+	}
+	return value, ""
+}
+
+// ociImageNames возвращает имена образов из index.json; если указан тег, только у манифеста с этим тегом
+func ociImageNames(data []byte, selector string) ([]string, error) {
+	var index ociIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, manifest := range index.Manifests {
+		if selector != "" && manifest.Annotations[annotationRefName] != selector {
+			continue
+		}
+		for _, key := range []string{annotationImageName, annotationRefName} {
+			if name := manifest.Annotations[key]; name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// dockerArchiveImageNames возвращает теги образов из manifest.json архива docker save
+func dockerArchiveImageNames(data []byte) ([]string, error) {
+	var manifest dockerArchiveManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, image := range manifest {
+		names = append(names, image.RepoTags...)
+	}
+	return names, nil
+}
+
+// readArchiveFile читает файл из корня tar-архива
+func readArchiveFile(path string, name string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := tar.NewReader(file)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("в архиве нет %s", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimPrefix(header.Name, "./") == name {
+			return io.ReadAll(reader)
+		}
+	}
+}
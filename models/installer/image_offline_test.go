@@ -0,0 +1,85 @@
+package installer
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestArchive создаёт tar-архив с одним файлом в корне
+func writeTestArchive(t *testing.T, path string, name string, data string) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	writer := tar.NewWriter(file)
+	if err := writer.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTargetImgref(t *testing.T) {
+	dir := t.TempDir()
+
+	ociDir := filepath.Join(dir, "oci")
+	if err := os.Mkdir(ociDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	index := `{"manifests": [
+		{"annotations": {"org.opencontainers.image.ref.name": "latest", "io.containerd.image.name": "ghcr.io/a/b:latest"}},
+		{"annotations": {"org.opencontainers.image.ref.name": "ghcr.io/a/b:testing"}}
+	]}`
+	if err := os.WriteFile(filepath.Join(ociDir, "index.json"), []byte(index), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ociArchive := filepath.Join(dir, "image-oci.tar")
+	writeTestArchive(t, ociArchive, "index.json", index)
+	dockerArchive := filepath.Join(dir, "image-docker.tar")
+	writeTestArchive(t, dockerArchive, "manifest.json", `[{"RepoTags": ["ghcr.io/a/b:docker"]}]`)
+	untagged := filepath.Join(dir, "untagged.tar")
+	writeTestArchive(t, untagged, "index.json", `{"manifests": [{"annotations": {"org.opencontainers.image.ref.name": "latest"}}]}`)
+
+	tests := []struct {
+		name     string
+		image    string
+		override string
+		want     string
+		wantErr  bool
+	}{
+		{name: "образ из реестра", image: "ghcr.io/a/b:latest", want: "ghcr.io/a/b:latest"},
+		{name: "явно заданный источник", image: "oci-archive:" + untagged, override: "ghcr.io/a/b:stable", want: "ghcr.io/a/b:stable"},
+		{name: "хранилище хоста", image: "containers-storage:ghcr.io/a/b:latest", want: "ghcr.io/a/b:latest"},
+		{name: "локально собранный образ", image: "containers-storage:localhost/b:latest", wantErr: true},
+		{name: "OCI-каталог", image: "oci:" + ociDir, want: "ghcr.io/a/b:latest"},
+		{name: "OCI-каталог с тегом", image: "oci:" + ociDir + ":ghcr.io/a/b:testing", want: "ghcr.io/a/b:testing"},
+		{name: "OCI-архив", image: "oci-archive:" + ociArchive, want: "ghcr.io/a/b:latest"},
+		{name: "docker-архив", image: "docker-archive:" + dockerArchive, want: "ghcr.io/a/b:docker"},
+		{name: "docker-архив с именем образа", image: "docker-archive:" + dockerArchive + ":ghcr.io/a/c:v1", want: "ghcr.io/a/c:v1"},
+		{name: "в архиве только тег", image: "oci-archive:" + untagged, wantErr: true},
+		{name: "архив не найден", image: "oci-archive:" + filepath.Join(dir, "missing.tar"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := targetImgref(tt.image, tt.override)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("targetImgref(%q) ошибка = %v, ожидалась ошибка: %t", tt.image, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("targetImgref(%q) = %q, ожидалось %q", tt.image, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package installer
+
+import (
+	"atomic-actions/models/installer/theme"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pullDir OCI-каталог для предварительной загрузки образа на временном разделе
+const pullDir = container_dir + "/atomic-actions-pull"
+
+// pullAttempts число попыток загрузки, уже скачанные слои при повторе не загружаются заново
+const pullAttempts = 3
+
+// pullPollInterval период опроса размера скачанных слоёв
+const pullPollInterval = 500 * time.Millisecond
+
+// pullTickMsg сигнал опроса прогресса загрузки
+type pullTickMsg time.Time
+
+// pullAttemptMsg начало очередной попытки загрузки, err — ошибка предыдущей
+type pullAttemptMsg struct {
+	attempt int
+	err     error
+}
+
+// pullDoneMsg загрузка завершена
+type pullDoneMsg struct {
+	err error
+}
+
+type PullProgress struct {
+	image      string       // Загружаемый образ
+	layers     []imageLayer // Слои образа из манифеста
+	total      int64        // Суммарный размер слоёв
+	downloaded int64        // Скачано байт, включая незавершённые слои
+	completed  map[string]bool
+
+	speed     float64   // Скорость загрузки, байт/с
+	lastBytes int64     // Скачано на момент прошлого опроса
+	lastTime  time.Time // Время прошлого опроса
+
+	attempt      int    // Номер текущей попытки
+	retryMessage string // Ошибка прошлой попытки
+	done         bool   // Загрузка завершена
+	aborted      bool   // Загрузка прервана пользователем
+	err          error  // Итоговая ошибка
+}
+
+// pullImage загружает образ из реестра во временное хранилище с отображением прогресса
+func pullImage(image string) error {
+	output, err := skopeoCommand("inspect", skopeoReference(image)).Output()
+	if err != nil {
+		return fmt.Errorf("ошибка получения манифеста %s: %v", image, err)
+	}
+
+	var inspect skopeoInspect
+	if err := json.Unmarshal(output, &inspect); err != nil {
+		return fmt.Errorf("ошибка разбора манифеста: %v", err)
+	}
+
+	if err := os.MkdirAll(pullDir, 0755); err != nil {
+		return fmt.Errorf("ошибка создания каталога %s: %v", pullDir, err)
+	}
+	defer os.RemoveAll(pullDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := tea.NewProgram(InitialPullProgress(image, inspect.LayersData))
+	go func() {
+		var err error
+		for attempt := 1; attempt <= pullAttempts; attempt++ {
+			p.Send(pullAttemptMsg{attempt: attempt, err: err})
+			if err = runSkopeoCopy(ctx, skopeoReference(image), "oci:"+pullDir+":install"); err == nil || ctx.Err() != nil {
+				break
+			}
+			// Пауза перед повтором растёт с каждой попыткой
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Duration(attempt) * 5 * time.Second):
+			}
+		}
+		p.Send(pullDoneMsg{err: err})
+	}()
+
+	model, err := p.Run()
+	if err != nil {
+		return fmt.Errorf("ошибка отображения прогресса загрузки: %v", err)
+	}
+
+	progress := model.(PullProgress)
+	if progress.aborted {
+		return fmt.Errorf("загрузка образа прервана")
+	}
+	if progress.err != nil {
+		return fmt.Errorf("ошибка загрузки образа %s: %v", image, progress.err)
+	}
+
+	log.Println("Копирование образа во временное хранилище...")
+	if err := runSkopeoCopy(context.Background(), "oci:"+pullDir+":install", "containers-storage:"+offlineImageName); err != nil {
+		return fmt.Errorf("ошибка копирования образа в хранилище: %v", err)
+	}
+	return nil
+}
+
+// runSkopeoCopy копирует образ, при ошибке возвращает последнюю строку вывода skopeo
+func runSkopeoCopy(ctx context.Context, source string, destination string) error {
+	args := []string{"copy", "--retry-times", "3"}
+	if registryAuthFile != "" {
+		args = append(args, "--authfile", registryAuthFile)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "skopeo", append(args, source, destination)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		return fmt.Errorf("%v: %s", err, lines[len(lines)-1])
+	}
+	return nil
+}
+
+func InitialPullProgress(image string, layers []imageLayer) PullProgress {
+	var total int64
+	for _, layer := range layers {
+		total += layer.Size
+	}
+	return PullProgress{
+		image:     image,
+		layers:    layers,
+		total:     total,
+		completed: make(map[string]bool),
+		lastTime:  time.Now(),
+	}
+}
+
+// pullTick планирует следующий опрос прогресса
+func pullTick() tea.Cmd {
+	return tea.Tick(pullPollInterval, func(t time.Time) tea.Msg {
+		return pullTickMsg(t)
+	})
+}
+
+func (m PullProgress) Init() tea.Cmd {
+	return pullTick()
+}
+
+// poll подсчитывает готовые слои в blobs/sha256 и незавершённые временные файлы skopeo
+func (m *PullProgress) poll(now time.Time) {
+	var downloaded int64
+	for _, layer := range m.layers {
+		hex := strings.TrimPrefix(layer.Digest, "sha256:")
+		if info, err := os.Stat(filepath.Join(pullDir, "blobs/sha256", hex)); err == nil {
+			m.completed[layer.Digest] = true
+			downloaded += info.Size()
+		}
+	}
+
+	partial, _ := filepath.Glob(filepath.Join(pullDir, "oci-put-blob*"))
+	for _, path := range partial {
+		if info, err := os.Stat(path); err == nil {
+			downloaded += info.Size()
+		}
+	}
+
+	// Скорость сглаживается, чтобы оценка времени не скакала между опросами
+	if elapsed := now.Sub(m.lastTime).Seconds(); elapsed > 0 && downloaded >= m.lastBytes {
+		current := float64(downloaded-m.lastBytes) / elapsed
+		m.speed = 0.7*m.speed + 0.3*current
+	}
+	m.lastBytes, m.lastTime, m.downloaded = downloaded, now, downloaded
+}
+
+func (m PullProgress) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case pullTickMsg:
+		if m.done {
+			return m, nil
+		}
+		m.poll(time.Time(msg))
+		return m, pullTick()
+	case pullAttemptMsg:
+		m.attempt = msg.attempt
+		if msg.err != nil {
+			m.retryMessage = msg.err.Error()
+		}
+	case pullDoneMsg:
+		m.done = true
+		m.err = msg.err
+		return m, tea.Quit
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			m.aborted = true
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m PullProgress) View() string {
+	header := theme.HeaderStyle.Render("Загрузка образа " + m.image)
+
+	body := ""
+	for i, layer := range m.layers {
+		status := theme.LoadingStyle.Render("ожидание")
+		if m.completed[layer.Digest] {
+			status = theme.SuccessStyle.Render("готово")
+		}
+		body += fmt.Sprintf("Слой %2d/%d %s %10s  %s\n", i+1, len(m.layers), shortDigest(layer.Digest), formatBytes(layer.Size), status)
+	}
+
+	percent := 0.0
+	if m.total > 0 {
+		percent = min(float64(m.downloaded)/float64(m.total)*100, 100)
+	}
+	body += fmt.Sprintf("\n%s %.0f%%  %s из %s\n", progressBar(percent, 40), percent, formatBytes(m.downloaded), formatBytes(m.total))
+
+	footer := "\n"
+	if m.speed > 0 {
+		eta := time.Duration(float64(max(m.total-m.downloaded, 0))/m.speed) * time.Second
+		footer += fmt.Sprintf("Скорость: %s/с, осталось: %s\n", formatBytes(int64(m.speed)), eta.Round(time.Second))
+	}
+	if m.attempt > 1 {
+		footer += theme.WarningsStyle.Render(fmt.Sprintf("Попытка %d из %d: %s", m.attempt, pullAttempts, m.retryMessage)) + "\n"
+	}
+	footer += "Ctrl+C — прервать загрузку.\n"
+
+	return header + "\n\n" + body + theme.FooterStyle.Render(footer)
+}
+
+// shortDigest возвращает первые 12 символов дайджеста
+func shortDigest(digest string) string {
+	hex := strings.TrimPrefix(digest, "sha256:")
+	if len(hex) > 12 {
+		return hex[:12]
+	}
+	return hex
+}
+
+// progressBar рисует полосу прогресса заданной ширины
+func progressBar(percent float64, width int) string {
+	filled := int(percent / 100 * float64(width))
+	return "[" + strings.Repeat("=", filled) + strings.Repeat(" ", width-filled) + "]"
+}
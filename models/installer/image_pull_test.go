@@ -0,0 +1,74 @@
+package installer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestShortDigest(t *testing.T) {
+	tests := []struct {
+		digest string
+		want   string
+	}{
+		{"sha256:0123456789abcdef0123", "0123456789ab"},
+		{"0123456789abcdef", "0123456789ab"},
+		{"sha256:abc", "abc"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.digest, func(t *testing.T) {
+			if got := shortDigest(tt.digest); got != tt.want {
+				t.Errorf("shortDigest(%q) = %q, ожидалось %q", tt.digest, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	tests := []struct {
+		percent float64
+		width   int
+		want    string
+	}{
+		{0, 4, "[    ]"},
+		{50, 4, "[==  ]"},
+		{99, 4, "[=== ]"},
+		{100, 4, "[====]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := progressBar(tt.percent, tt.width); got != tt.want {
+				t.Errorf("progressBar(%v, %d) = %q, ожидалось %q", tt.percent, tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPullProgressUpdate(t *testing.T) {
+	m := InitialPullProgress("ghcr.io/alt-atomic/onyx", []imageLayer{
+		{Digest: "sha256:aaa", Size: 100},
+		{Digest: "sha256:bbb", Size: 300},
+	})
+	if m.total != 400 {
+		t.Fatalf("total = %d, ожидалось 400", m.total)
+	}
+
+	model, _ := m.Update(pullAttemptMsg{attempt: 2, err: errors.New("connection reset")})
+	m = model.(PullProgress)
+	if m.attempt != 2 || m.retryMessage != "connection reset" {
+		t.Errorf("после повтора attempt = %d, retryMessage = %q", m.attempt, m.retryMessage)
+	}
+
+	model, cmd := m.Update(pullDoneMsg{err: errors.New("manifest unknown")})
+	m = model.(PullProgress)
+	if !m.done || m.err == nil || cmd == nil {
+		t.Errorf("после завершения done = %t, err = %v, cmd = %v", m.done, m.err, cmd)
+	}
+
+	// После завершения опрос прогресса больше не планируется
+	if _, cmd := m.Update(pullTickMsg{}); cmd != nil {
+		t.Error("опрос продолжается после завершения загрузки")
+	}
+}
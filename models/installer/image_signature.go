@@ -0,0 +1,155 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// containersPolicyPath политика проверки подписей containers-policy.json(5), её же применяют podman и skopeo
+const containersPolicyPath = "/etc/containers/policy.json"
+
+// SignatureStatus результат проверки подписи образа
+type SignatureStatus struct {
+	Signed   bool   // В реестре найдена подпись sigstore
+	Verified bool   // Подпись проверена cosign ключом из политики
+	Enforced bool   // Политика требует подпись для этого реестра, podman проверит её при загрузке
+	Signer   string // Ключ, которым подписан образ
+	Message  string // Пояснение, если подпись не найдена или не прошла проверку
+}
+
+// containersPolicy часть policy.json, нужная для поиска требований к подписи
+type containersPolicy struct {
+	Transports map[string]map[string][]policyRequirement `json:"transports"`
+}
+
+type policyRequirement struct {
+	Type     string   `json:"type"`
+	KeyPath  string   `json:"keyPath"`
+	KeyPaths []string `json:"keyPaths"` // Подпись принимается, если её подтверждает любой из ключей
+}
+
+// Trusted сообщает, что подпись образа проверена установщиком. Политика podman при загрузке
+// не в счёт: к моменту загрузки диск уже размечен.
+func (s SignatureStatus) Trusted() bool {
+	return s.Verified
+}
+
+// Describe возвращает индикатор подписи для шага выбора образа
+func (s SignatureStatus) Describe() string {
+	switch {
+	case s.Verified:
+		return "подписан: " + s.Signer
+	case s.Enforced:
+		return "подпись не проверена, cosign не установлен, podman проверит её при загрузке: " + s.Signer
+	case s.Signed:
+		return "подпись найдена, но не проверена: " + s.Message
+	default:
+		return "без подписи: " + s.Message
+	}
+}
+
+// imageRepository возвращает репозиторий образа без тега и дайджеста
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// policyKeyPaths читает policy.json и ищет в нём ключи sigstoreSigned для репозитория
+func policyKeyPaths(repository string) []string {
+	data, err := os.ReadFile(containersPolicyPath)
+	if err != nil {
+		return nil
+	}
+
+	var policy containersPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil
+	}
+	return policy.keyPaths(repository)
+}
+
+// keyPaths ищет ключи sigstoreSigned для репозитория, от самой точной области политики к реестру
+// и дальше к области "" — умолчанию для транспорта docker. Ключи берутся из первой области, где они заданы.
+func (p containersPolicy) keyPaths(repository string) []string {
+	scopes := p.Transports["docker"]
+	var candidates []string
+	for scope := repository; scope != "" && scope != "."; scope = filepath.Dir(scope) {
+		candidates = append(candidates, scope)
+	}
+	candidates = append(candidates, "")
+
+	for _, scope := range candidates {
+		var keyPaths []string
+		for _, requirement := range scopes[scope] {
+			if requirement.Type != "sigstoreSigned" {
+				continue
+			}
+			if requirement.KeyPath != "" {
+				keyPaths = append(keyPaths, requirement.KeyPath)
+			}
+			keyPaths = append(keyPaths, requirement.KeyPaths...)
+		}
+		if len(keyPaths) > 0 {
+			return keyPaths
+		}
+	}
+	return nil
+}
+
+// imageDigest возвращает дайджест манифеста образа
+func imageDigest(image string) (string, error) {
+	output, err := skopeoCommand("inspect", "--format", "{{.Digest}}", skopeoReference(image)).Output()
+	if err != nil {
+		return "", fmt.Errorf("ошибка получения дайджеста %s: %v", image, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// checkImageSignature ищет подпись cosign (тег sha256-<дайджест>.sig) для манифеста digest и проверяет её ключом
+// из policy.json. Проверяется именно тот дайджест, по которому образ будет установлен.
+func checkImageSignature(image string, digest string) SignatureStatus {
+	if isLocalImage(image) {
+		return SignatureStatus{Message: "подписи sigstore проверяются только для образов из реестра"}
+	}
+
+	repository := imageRepository(image)
+	signatureTag := repository + ":" + strings.Replace(digest, ":", "-", 1) + ".sig"
+	if err := skopeoCommand("inspect", "--raw", "docker://"+signatureTag).Run(); err != nil {
+		return SignatureStatus{Message: "подпись в реестре не найдена"}
+	}
+
+	status := SignatureStatus{Signed: true}
+	keyPaths := policyKeyPaths(repository)
+	if len(keyPaths) == 0 {
+		status.Message = "ключ для " + repository + " не задан в " + containersPolicyPath
+		return status
+	}
+	status.Signer = strings.Join(keyPaths, ", ")
+
+	if _, err := exec.LookPath("cosign"); err != nil {
+		// cosign не установлен, но podman проверит подпись по политике при загрузке образа
+		status.Enforced = true
+		return status
+	}
+
+	// Подпись принимается, если её подтверждает любой из ключей политики
+	for _, keyPath := range keyPaths {
+		if err := exec.Command("cosign", "verify", "--key", keyPath, repository+"@"+digest).Run(); err == nil {
+			status.Signer = keyPath
+			status.Verified = true
+			return status
+		}
+	}
+
+	status.Message = "подпись не прошла проверку ключом " + status.Signer
+	return status
+}
@@ -0,0 +1,71 @@
+package installer
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestImageRepository(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{"ghcr.io/alt-atomic/onyx", "ghcr.io/alt-atomic/onyx"},
+		{"ghcr.io/alt-atomic/onyx:latest", "ghcr.io/alt-atomic/onyx"},
+		{"ghcr.io/alt-atomic/onyx@sha256:abc", "ghcr.io/alt-atomic/onyx"},
+		{"ghcr.io/alt-atomic/onyx:latest@sha256:abc", "ghcr.io/alt-atomic/onyx"},
+		{"registry.local:5000/onyx", "registry.local:5000/onyx"},
+		{"registry.local:5000/onyx:dev", "registry.local:5000/onyx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			if got := imageRepository(tt.image); got != tt.want {
+				t.Errorf("imageRepository(%q) = %q, ожидалось %q", tt.image, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainersPolicyKeyPaths(t *testing.T) {
+	data := `{
+		"default": [{"type": "insecureAcceptAnything"}],
+		"transports": {
+			"docker": {
+				"": [{"type": "sigstoreSigned", "keyPath": "/etc/pki/default.pub"}],
+				"ghcr.io/alt-atomic": [{"type": "sigstoreSigned", "keyPaths": ["/etc/pki/a.pub", "/etc/pki/b.pub"]}],
+				"ghcr.io/alt-atomic/onyx": [{"type": "sigstoreSigned", "keyPath": "/etc/pki/onyx.pub"}],
+				"ghcr.io/other": [{"type": "insecureAcceptAnything"}]
+			}
+		}
+	}`
+	var policy containersPolicy
+	if err := json.Unmarshal([]byte(data), &policy); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		repository string
+		want       []string
+	}{
+		{name: "точная область", repository: "ghcr.io/alt-atomic/onyx", want: []string{"/etc/pki/onyx.pub"}},
+		{name: "область пространства имён с несколькими ключами", repository: "ghcr.io/alt-atomic/kde", want: []string{"/etc/pki/a.pub", "/etc/pki/b.pub"}},
+		{name: "требование без ключа: область по умолчанию", repository: "ghcr.io/other/image", want: []string{"/etc/pki/default.pub"}},
+		{name: "нет области: область по умолчанию", repository: "docker.io/library/alpine", want: []string{"/etc/pki/default.pub"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.keyPaths(tt.repository); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keyPaths(%q) = %v, ожидалось %v", tt.repository, got, tt.want)
+			}
+		})
+	}
+
+	var empty containersPolicy
+	if got := empty.keyPaths("ghcr.io/alt-atomic/onyx"); got != nil {
+		t.Errorf("keyPaths() без политики = %v, ожидалось nil", got)
+	}
+}
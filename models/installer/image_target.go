@@ -0,0 +1,155 @@
+package installer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// minImageSizeGb минимальный размер файла образа: root-раздел заканчивается на 25000MiB,
+// остальное место отводится под временный раздел с хранилищем контейнеров
+const minImageSizeGb = 40
+
+// DiskImage файл образа диска, подключённый как loop-устройство
+type DiskImage struct {
+	Path       string // Итоговый путь к образу
+	Format     string // Формат образа: raw или qcow2
+	LoopDevice string // Подключённое loop-устройство
+	rawPath    string // Путь к raw-файлу, в который выполняется установка
+}
+
+// imageFormatFromPath определяет формат образа по расширению файла
+func imageFormatFromPath(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".qcow2") {
+		return "qcow2"
+	}
+	return "raw"
+}
+
+// checkTargetSize проверяет размер диска или файла образа. Минимум из каталога относится к диску, на котором
+// система будет работать, а размер файла образа выбирает пользователь — для него проверяется только minImageSizeGb
+func checkTargetSize(entry *CatalogImage, sizeGb float64, toImage bool) error {
+	if !toImage {
+		return checkCatalogDiskSize(entry, sizeGb)
+	}
+	if sizeGb < minImageSizeGb {
+		return fmt.Errorf("размер образа должен быть не меньше %d ГБ, указано %.0f ГБ", minImageSizeGb, sizeGb)
+	}
+	return nil
+}
+
+// createDiskImage создаёт разреженный файл указанного размера и подключает его как loop-устройство
+func createDiskImage(path string, size string, format string) (*DiskImage, error) {
+	sizeGb, err := parseSize(size)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора размера образа: %v", err)
+	}
+	if sizeGb < minImageSizeGb {
+		return nil, fmt.Errorf("размер образа должен быть не меньше %d ГБ, указано %s", minImageSizeGb, size)
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("файл %s уже существует", path)
+	}
+
+	image := &DiskImage{Path: path, Format: format, rawPath: path}
+	if format == "qcow2" {
+		// Установка выполняется в raw-файл, который по завершении конвертируется в qcow2
+		image.rawPath = path + ".raw"
+		if _, err := os.Stat(image.rawPath); err == nil {
+			return nil, fmt.Errorf("файл %s уже существует", image.rawPath)
+		}
+	}
+
+	log.Printf("Создание разреженного файла %s размером %s...\n", image.rawPath, size)
+	file, err := os.OpenFile(image.rawPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания файла образа: %v", err)
+	}
+
+	if err := file.Truncate(int64(sizeGb * (1 << 30))); err != nil {
+		file.Close()
+		os.Remove(image.rawPath)
+		return nil, fmt.Errorf("ошибка изменения размера файла образа: %v", err)
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(image.rawPath)
+		return nil, fmt.Errorf("ошибка закрытия файла образа: %v", err)
+	}
+
+	// Подключаем файл с поиском разделов, чтобы ядро создало устройства loopXpN
+	output, err := exec.Command("losetup", "--find", "--show", "--partscan", image.rawPath).Output()
+	if err != nil {
+		os.Remove(image.rawPath)
+		return nil, fmt.Errorf("ошибка подключения loop-устройства: %v", err)
+	}
+
+	image.LoopDevice = strings.TrimSpace(string(output))
+	log.Printf("Файл образа %s подключён как %s\n", image.rawPath, image.LoopDevice)
+	return image, nil
+}
+
+// detach размонтирует оставшиеся точки монтирования установщика и отключает loop-устройство
+func (d *DiskImage) detach() error {
+	if d.LoopDevice == "" {
+		return nil
+	}
+
+	paths := []string{"/mnt/target/boot/efi", "/mnt/target/boot", container_dir, "/mnt/target"}
+	for _, path := range paths {
+		_ = unmount(path)
+	}
+
+	_ = exec.Command("sync").Run()
+
+	log.Printf("Отключение loop-устройства %s...\n", d.LoopDevice)
+	cmd := exec.Command("losetup", "--detach", d.LoopDevice)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ошибка отключения loop-устройства %s: %v", d.LoopDevice, err)
+	}
+
+	d.LoopDevice = ""
+	return nil
+}
+
+// finalize отключает loop-устройство и при необходимости конвертирует образ в qcow2
+func (d *DiskImage) finalize() error {
+	if err := d.detach(); err != nil {
+		return err
+	}
+
+	if d.Format != "qcow2" {
+		return nil
+	}
+
+	log.Printf("Конвертация %s в qcow2...\n", d.rawPath)
+	cmd := exec.Command("qemu-img", "convert", "-p", "-f", "raw", "-O", "qcow2", d.rawPath, d.Path)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ошибка конвертации образа в qcow2: %v", err)
+	}
+
+	if err := os.Remove(d.rawPath); err != nil {
+		return fmt.Errorf("ошибка удаления временного raw-файла %s: %v", d.rawPath, err)
+	}
+	return nil
+}
+
+// discard отключает loop-устройство после неудачной установки и удаляет недособранный образ
+func (d *DiskImage) discard() {
+	if err := d.detach(); err != nil {
+		log.Println(err)
+		return
+	}
+
+	if err := os.Remove(d.rawPath); err != nil {
+		log.Printf("Ошибка удаления файла образа %s: %v\n", d.rawPath, err)
+	}
+}
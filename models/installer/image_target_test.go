@@ -0,0 +1,30 @@
+package installer
+
+import "testing"
+
+func TestCheckTargetSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   *CatalogImage
+		sizeGb  float64
+		toImage bool
+		wantErr bool
+	}{
+		{name: "файл образа 40G для каждого образа каталога", entry: &builtinCatalog[0], sizeGb: 40, toImage: true},
+		{name: "файл образа 40G для образа NVIDIA", entry: &builtinCatalog[1], sizeGb: 40, toImage: true},
+		{name: "файл образа меньше минимума разметки", entry: &builtinCatalog[0], sizeGb: 39, toImage: true, wantErr: true},
+		{name: "файл образа для образа не из каталога", entry: nil, sizeGb: 40, toImage: true},
+		{name: "диск меньше минимума каталога", entry: &builtinCatalog[0], sizeGb: 40, wantErr: true},
+		{name: "диск по минимуму каталога", entry: &builtinCatalog[0], sizeGb: float64(builtinCatalog[0].MinDiskGb)},
+		{name: "диск для образа не из каталога", entry: nil, sizeGb: 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTargetSize(tt.entry, tt.sizeGb, tt.toImage)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ошибка = %v, ожидалась ошибка: %t", err, tt.wantErr)
+			}
+		})
+	}
+}
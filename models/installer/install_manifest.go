@@ -0,0 +1,86 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Version версия установщика, задаётся при сборке: -ldflags "-X atomic-actions/models/installer.Version=1.0.0"
+var Version = "dev"
+
+// installManifestPath файл с происхождением установки в /etc развёртывания
+const installManifestPath = "/etc/atomic-actions/install.json"
+
+// InstallManifest сведения об установке для последующего аудита
+type InstallManifest struct {
+	Image            string          `json:"image"`
+	Digest           string          `json:"digest,omitempty"`
+	InstallerVersion string          `json:"installer_version"`
+	Date             string          `json:"date"`
+	Options          ManifestOptions `json:"options"`
+}
+
+// ManifestOptions параметры, выбранные при установке
+type ManifestOptions struct {
+	Filesystem   string        `json:"filesystem"`
+	BtrfsProfile *BtrfsProfile `json:"btrfs_profile,omitempty"`
+	BootMode     string        `json:"boot_mode"`
+	Swap         string        `json:"swap"`
+	SwapSizeMiB  int           `json:"swap_size_mib,omitempty"`
+	Hibernate    bool          `json:"hibernate,omitempty"`
+	KernelArgs   []string      `json:"kernel_args,omitempty"`
+	EnrollMok    bool          `json:"enroll_mok,omitempty"`
+	GenericImage bool          `json:"generic_image,omitempty"`
+}
+
+// pinnedImage возвращает ссылку на образ по дайджесту, чтобы установка не зависела от перемещения тега
+func pinnedImage(config *InstallConfig) string {
+	if config.ImageDigest == "" || isLocalImage(config.Image) {
+		return config.Image
+	}
+	return imageRepository(config.Image) + "@" + config.ImageDigest
+}
+
+// writeInstallManifest записывает install.json в /etc развёртывания
+func writeInstallManifest(config *InstallConfig, ostreeDeployPath string) error {
+	manifest := InstallManifest{
+		Image:            config.Image,
+		Digest:           config.ImageDigest,
+		InstallerVersion: Version,
+		Date:             time.Now().UTC().Format(time.RFC3339),
+		Options: ManifestOptions{
+			Filesystem:   config.Filesystem.Name(),
+			BootMode:     config.BootMode,
+			Swap:         config.Swap.Mode,
+			SwapSizeMiB:  config.Swap.SizeMiB,
+			Hibernate:    config.Swap.Hibernate,
+			KernelArgs:   config.KernelArgs,
+			EnrollMok:    config.EnrollMok,
+			GenericImage: config.GenericImage,
+		},
+	}
+	if btrfs, ok := config.Filesystem.(*btrfsDriver); ok {
+		manifest.Options.BtrfsProfile = &btrfs.Profile
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ошибка формирования %s: %v", installManifestPath, err)
+	}
+
+	manifestPath := filepath.Join(ostreeDeployPath, installManifestPath)
+	if err := os.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
+		return fmt.Errorf("ошибка создания каталога %s: %v", filepath.Dir(manifestPath), err)
+	}
+
+	if err := os.WriteFile(manifestPath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("ошибка записи %s: %v", manifestPath, err)
+	}
+
+	log.Printf("Файл %s успешно создан.\n", manifestPath)
+	return nil
+}
@@ -2,11 +2,13 @@ package installer
 
 import (
 	"atomic-actions/models/installer/utility"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -16,76 +18,330 @@ const container_dir = "/var/lib/containers"
 
 var timezone = "Europe/Moscow"
 
-func RunInstaller() {
+func RunInstaller(args []string) {
+	options, err := parseInstallOptions(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatalf("Ошибка разбора параметров: %v\n", err)
+	}
+
+	swap, err := swapFromOptions(options)
+	if err != nil {
+		log.Fatalf("Ошибка разбора параметров: %v\n", err)
+	}
+
+	registry, err := registryFromOptions(options)
+	if err != nil {
+		log.Fatalf("Ошибка разбора параметров: %v\n", err)
+	}
+
 	checkRoot()
 	go checkTimeZone()
 
+	// Каталог читается один раз и используется на всех шагах установки
+	catalog := loadImageCatalog()
+	hardware := probeHardware()
+	printHardwareSummary(hardware, recommendedCatalogImage(catalog, hardware.Tags()))
+
 	// Проверка наличия необходимых команд
-	if err := checkCommands(); err != nil {
+	if err := checkCommands(options); err != nil {
 		log.Fatalf("Необходимая команда отсутствует: %v\n", err)
 	}
 
+	// Файл образа диска может загружаться на любой машине, поэтому устанавливается как generic
+	config := &InstallConfig{
+		GenericImage:  options.ToImage != "",
+		EnrollMok:     options.EnrollMok,
+		KeepBootOrder: options.KeepBootOrder,
+		ReportPath:    options.ReportPath,
+	}
+
+	// Учётные данные и зеркала нужны уже на шаге выбора образа
+	if registry.AuthFile != "" {
+		if err := useAuthFile(registry.AuthFile); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	if err := registry.useRegistriesConf(); err != nil {
+		log.Fatalln(err)
+	}
+
 	// Шаг 1: Выбор образа
-	imageResult := RunImageStep()
-	if imageResult == "" {
+	config.Image = RunImageStep(&registry, catalog, hardware.Tags())
+	if config.Image == "" {
 		log.Println("Образ не был выбран.")
 		return
 	}
-	log.Printf("Выбранный образ: %s\n\n", imageResult)
+	log.Printf("Выбранный образ: %s\n\n", config.Image)
+	config.Registry = registry
 
-	// Шаг 2: Выбор диска
-	diskResult := RunDiskStep()
-	if diskResult == "" {
-		log.Println("Диск не был выбран.")
-		return
+	// Локальный образ устанавливается под временным именем, обновления должны идти из реестра
+	config.TargetImgref, err = targetImgref(config.Image, options.TargetImgref)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if config.TargetImgref != config.Image {
+		log.Printf("Обновления будут загружаться из %s\n", config.TargetImgref)
+	}
+	catalogImage := findCatalogImage(catalog, config.Image)
+
+	// Тег может переместиться во время установки, поэтому образ закрепляется по дайджесту
+	config.ImageDigest, err = imageDigest(config.Image)
+	if err != nil {
+		log.Fatalf("Ошибка получения дайджеста образа: %v\n", err)
 	}
+	log.Printf("Дайджест образа: %s\n", config.ImageDigest)
+
+	if options.RequireSigned {
+		status := checkImageSignature(config.Image, config.ImageDigest)
+		if !status.Trusted() {
+			log.Fatalf("Образ %s отклонён (--require-signed): %s\n", config.Image, status.Describe())
+		}
+		log.Printf("Подпись образа: %s\n", status.Describe())
+	}
+
+	// Шаг 2: Выбор диска, при установке в файл образа диск создаётся позже
+	var targetSizeMiB int
+	if options.ToImage == "" {
+		config.Disk = RunDiskStep()
+		if config.Disk == "" {
+			log.Println("Диск не был выбран.")
+			return
+		}
 
-	if !validateDisk(diskResult) {
-		log.Fatalf("Выбранный диск %s недействителен или не существует.\n", diskResult)
+		if !validateDisk(config.Disk) {
+			log.Fatalf("Выбранный диск %s недействителен или не существует.\n", config.Disk)
+		}
+
+		targetSizeMiB, err = diskSizeMiB(config.Disk)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		if err := checkTargetSize(catalogImage, float64(targetSizeMiB)/1024, false); err != nil {
+			log.Fatalln(err)
+		}
+	} else {
+		sizeGb, err := parseSize(options.ImageSize)
+		if err != nil {
+			log.Fatalf("Ошибка разбора размера образа: %v\n", err)
+		}
+		if err := checkTargetSize(catalogImage, sizeGb, true); err != nil {
+			log.Fatalln(err)
+		}
+		targetSizeMiB = int(sizeGb * 1024)
 	}
 
-	// Шаг 3: Выбор файловой системы
-	typeFileSystem := RunFilesystemStep()
+	// Шаг 3: Выбор файловой системы, по умолчанию предлагается рекомендованная каталогом
+	recommendedFilesystem := ""
+	if catalogImage != nil {
+		recommendedFilesystem = catalogImage.RecommendedFilesystem
+	}
+	typeFileSystem := RunFilesystemStep(recommendedFilesystem)
 	if typeFileSystem == "" {
 		log.Println("Файловая система не выбрана.")
 		return
 	}
 
+	config.Filesystem, err = getFilesystemDriver(typeFileSystem)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	// Разметка подтомов btrfs: из файла профиля или интерактивно
+	btrfs, isBtrfs := config.Filesystem.(*btrfsDriver)
+	if isBtrfs {
+		if options.BtrfsProfile != "" {
+			btrfs.Profile, err = loadBtrfsProfile(options.BtrfsProfile)
+			if err != nil {
+				log.Fatalln(err)
+			}
+		} else {
+			profile := RunBtrfsOptionsStep(btrfs.Profile)
+			if profile == nil {
+				log.Println("Настройка btrfs отменена.")
+				return
+			}
+			btrfs.Profile = *profile
+		}
+	}
+
+	if err := checkCommandsAvailable(config.Filesystem.Commands()); err != nil {
+		log.Fatalf("Необходимая команда отсутствует: %v\n", err)
+	}
+
+	// Модули без подписи дистрибутива не загрузятся при включённом Secure Boot без собственного ключа MOK
+	secureBootWarning := ""
+	if hardware.SecureBoot.Enabled && imageHasUnsignedModules(catalogImage, config.Image) && !config.EnrollMok {
+		secureBootWarning = fmt.Sprintf("Secure Boot включён, а образ %s содержит модули ядра без подписи. "+
+			"Отключите Secure Boot или запустите установку с --enroll-mok.", config.Image)
+		log.Printf("Предупреждение: %s\n", secureBootWarning)
+	}
+
 	// Шаг 4: Выбор типа загрузки
-	typeBoot := RunBootModeStep()
-	if typeBoot == "" {
+	config.BootMode = RunBootModeStep(hardware.RecommendedBootMode(), secureBootWarning)
+	if config.BootMode == "" {
 		log.Println("Boot режим не выбран.")
 		return
 	}
 
-	// Шаг 5: Добавление юзера (*UserCreation модель)
+	if needsEfiBootEntry(config) {
+		if err := checkCommandsAvailable([]string{"efibootmgr"}); err != nil {
+			log.Fatalf("Необходимая команда отсутствует: %v\n", err)
+		}
+	}
+
+	// Дополнительные аргументы ядра: из командной строки или интерактивно
+	if len(options.KernelArgs) > 0 {
+		config.KernelArgs = options.KernelArgs
+	} else {
+		kargs, ok := RunKernelArgsStep(config.Filesystem.RootMountOptions())
+		if !ok {
+			log.Println("Ввод аргументов ядра отменён.")
+			return
+		}
+		config.KernelArgs = kargs
+	}
+
+	// Шаг 5: Выбор подкачки
+	if swap == nil {
+		swap = RunSwapStep(hardware.RecommendedSwap(swapSpaceMiB(targetSizeMiB)))
+		if swap == nil {
+			log.Println("Подкачка не выбрана.")
+			return
+		}
+	}
+	config.Swap = *swap
+
+	// Файл подкачки на btrfs размещается в отдельном подтоме без copy-on-write
+	if isBtrfs && config.Swap.Mode == "file" {
+		btrfs.Profile.addSubvolume(swapSubvolume())
+	}
+
+	// Шаг 6: Добавление юзера (*UserCreation модель)
 	user, errorUser := RunUserCreationStep()
 	if errorUser != nil {
 		log.Println(errorUser)
 		return
 	}
+	config.User = user
+
+	// Шаг 7: Пароль MOK, отдельный от пароля пользователя: он вводится один раз в MokManager
+	if needsMokEnrollment(config) {
+		password, ok := RunMokPasswordStep()
+		if !ok {
+			log.Println("Ввод пароля MOK отменён.")
+			return
+		}
+		config.MokPassword = password
+	}
+
+	// Создаём файл образа и подключаем его как loop-устройство
+	var diskImage *DiskImage
+	if options.ToImage != "" {
+		diskImage, err = createDiskImage(options.ToImage, options.ImageSize, options.ImageFormat)
+		if err != nil {
+			log.Fatalf("Ошибка создания файла образа: %v\n", err)
+		}
+		config.Disk = diskImage.LoopDevice
+	}
+
+	if err := runInstall(config); err != nil {
+		if diskImage != nil {
+			diskImage.discard()
+		}
+		log.Fatalln(err)
+	}
 
+	// Система уже установлена, поэтому при непройденной проверке образ диска всё равно сохраняется для разбора
+	passed := checkInstallation(config)
+
+	if diskImage != nil {
+		if err := diskImage.finalize(); err != nil {
+			log.Fatalf("Ошибка завершения работы с файлом образа: %v\n", err)
+		}
+		log.Printf("Образ диска (%s) сохранён: %s\n", diskImage.Format, diskImage.Path)
+	}
+
+	if !passed {
+		log.Println("Установка завершена, но проверка установленной системы не пройдена.")
+		os.Exit(1)
+	}
+	log.Println("Установка завершена успешно!")
+}
+
+// runInstall размечает диск, устанавливает образ и удаляет временный раздел, проверка выполняется отдельно в checkInstallation
+func runInstall(config *InstallConfig) error {
 	// проверяем размер /tmp
 	checkAndRemountTmp()
 
-	if err := prepareDisk(diskResult, typeFileSystem, typeBoot); err != nil {
-		log.Fatalf("Ошибка подготовки диска: %v\n", err)
+	// Разметка меняет PARTUUID, поэтому записи NVRAM прошлых установок на этот диск находятся по старым
+	previousPartUUIDs := diskPartUUIDs(config.Disk)
+
+	if err := prepareDisk(config); err != nil {
+		return fmt.Errorf("ошибка подготовки диска: %v", err)
 	}
 
-	if err := installToFilesystem(imageResult, diskResult, typeBoot, typeFileSystem, user); err != nil {
-		log.Fatalf("Ошибка установки: %v\n", err)
+	// Образ из реестра загружается до установки, чтобы показать прогресс и повторить загрузку при сбое сети
+	if !isLocalImage(config.Image) {
+		if err := pullImage(pinnedImage(config)); err != nil {
+			return fmt.Errorf("ошибка загрузки образа: %v", err)
+		}
+	}
+
+	if err := installToFilesystem(config); err != nil {
+		return fmt.Errorf("ошибка установки: %v", err)
 	}
 
-	partitions, err := getNamedPartitions(diskResult, typeBoot)
+	partitions, err := getNamedPartitions(config.Disk, config.BootMode)
 	if err != nil {
-		log.Fatalf("Ошибка получения именованных разделов: %v", err)
+		return fmt.Errorf("ошибка получения именованных разделов: %v", err)
 	}
 
-	if err := cleanupTemporaryPartition(partitions, diskResult); err != nil {
-		log.Fatalf("Ошибка очистки временного раздела: %v", err)
+	if config.BootMode == "HYBRID" {
+		if err := verifyHybridBoot(config.Disk, partitions); err != nil {
+			return fmt.Errorf("ошибка проверки гибридной загрузки: %v", err)
+		}
 	}
 
-	log.Println("Установка завершена успешно!")
+	if err := cleanupTemporaryPartition(partitions, config.Disk, config.Filesystem); err != nil {
+		return fmt.Errorf("ошибка очистки временного раздела: %v", err)
+	}
+
+	// Система уже установлена и загрузится по резервному пути, поэтому ошибка записи NVRAM не прерывает установку
+	if needsEfiBootEntry(config) {
+		if err := createEfiBootEntry(config.Disk, partitions, previousPartUUIDs, config.KeepBootOrder); err != nil {
+			log.Printf("Предупреждение: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// checkInstallation проверяет установленную систему и сохраняет отчёт, false — проверки не пройдены
+// или систему не удалось проверить. Ошибка записи отчёта выводится как предупреждение.
+func checkInstallation(config *InstallConfig) bool {
+	report, err := verifyInstallation(config)
+	if err != nil {
+		// Непроверенная система не считается успешно установленной, отчёт сохраняется с причиной
+		report = newVerificationReport(config)
+		report.add("setup", fmt.Errorf("проверка не выполнена: %v", err))
+	}
+
+	reportPath, err := writeVerificationReport(report, config.ReportPath)
+	if err != nil {
+		log.Printf("Предупреждение: %v\n", err)
+	} else {
+		log.Printf("Отчёт проверки сохранён: %s\n", reportPath)
+	}
+
+	if !report.Passed && reportPath != "" {
+		log.Printf("Проверка установленной системы не пройдена, подробности в %s\n", reportPath)
+	} else if !report.Passed {
+		log.Println("Проверка установленной системы не пройдена.")
+	}
+	return report.Passed
 }
 
 func checkAndRemountTmp() {
@@ -124,7 +380,7 @@ func checkTimeZone() {
 	timezone = ipTimeZone
 }
 
-func cleanupTemporaryPartition(partitions map[string]PartitionInfo, diskResult string) error {
+func cleanupTemporaryPartition(partitions map[string]PartitionInfo, diskResult string, rootFileSystem FilesystemDriver) error {
 	log.Println("Удаление временного раздела и расширение root-раздела...")
 
 	// Размонтируем временный раздел
@@ -142,9 +398,18 @@ func cleanupTemporaryPartition(partitions map[string]PartitionInfo, diskResult s
 		return fmt.Errorf("ошибка удаления временного раздела: %v", err)
 	}
 
-	// Расширяем root-раздел
-	log.Printf("Расширение root-раздела %s до 100%%...\n", partitions["root"].Path)
-	cmd = exec.Command("parted", "-s", diskResult, "resizepart", partitions["root"].Number, "100%")
+	// Расширяем root-раздел до конца диска или до раздела подкачки
+	rootEnd := "100%"
+	if swap, exists := partitions["swap"]; exists {
+		swapStart, err := partitionStartSector(diskResult, swap.Number)
+		if err != nil {
+			return err
+		}
+		rootEnd = fmt.Sprintf("%ds", swapStart-1)
+	}
+
+	log.Printf("Расширение root-раздела %s до %s...\n", partitions["root"].Path, rootEnd)
+	cmd = exec.Command("parted", "-s", diskResult, "resizepart", partitions["root"].Number, rootEnd)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
@@ -162,47 +427,35 @@ func cleanupTemporaryPartition(partitions map[string]PartitionInfo, diskResult s
 	fsType := strings.TrimSpace(string(output))
 	log.Printf("Тип файловой системы: %s\n", fsType)
 
-	if fsType == "btrfs" {
-		// Для btrfs используем btrfs filesystem resize
-		mountPoint := "/mnt/btrfs-root"
-		log.Printf("Изменение размера файловой системы btrfs на разделе %s...\n", partitions["root"].Path)
+	if fsType != rootFileSystem.Name() {
+		return fmt.Errorf("неожиданная файловая система root-раздела: %s, ожидалась %s", fsType, rootFileSystem.Name())
+	}
 
-		// Монтируем раздел
-		if err := mountDisk(partitions["root"].Path, mountPoint, ""); err != nil {
-			return fmt.Errorf("ошибка монтирования btrfs-раздела: %v", err)
-		}
-		defer unmountDisk(mountPoint) // Размонтируем после завершения
+	if err := rootFileSystem.Grow(partitions["root"].Path); err != nil {
+		return err
+	}
 
-		// Выполняем resize на точке монтирования
-		cmd = exec.Command("btrfs", "filesystem", "resize", "max", mountPoint)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("ошибка изменения размера файловой системы btrfs: %v", err)
-		}
-	} else if fsType == "ext4" {
-		// Для ext4 используем resize2fs
-		cmd = exec.Command("e2fsck", "-f", "-y", partitions["root"].Path)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		log.Printf("Проверка и исправление файловой системы ext4 на разделе %s...\n", partitions["root"].Path)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("ошибка проверки файловой системы ext4: %v", err)
-		}
+	log.Println("Временный раздел удалён, root-раздел расширен.")
+	return nil
+}
 
-		log.Printf("Изменение размера файловой системы ext4 на разделе %s...\n", partitions["root"].Path)
-		cmd = exec.Command("resize2fs", partitions["root"].Path)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("ошибка изменения размера файловой системы ext4: %v", err)
+// partitionStartSector возвращает начальный сектор раздела по выводу parted в машинном формате
+func partitionStartSector(disk string, number string) (int64, error) {
+	output, err := exec.Command("parted", "-s", "-m", disk, "unit", "s", "print").Output()
+	if err != nil {
+		return 0, fmt.Errorf("ошибка чтения таблицы разделов %s: %v", disk, err)
+	}
+
+	// Строки разделов имеют вид "5:123456s:234567s:111112s:linux-swap(v1)::swap;"
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 || fields[0] != number {
+			continue
 		}
-	} else {
-		return fmt.Errorf("неподдерживаемая файловая система: %s", fsType)
+		return strconv.ParseInt(strings.TrimSuffix(fields[1], "s"), 10, 64)
 	}
 
-	log.Println("Временный раздел удалён, root-раздел расширен.")
-	return nil
+	return 0, fmt.Errorf("раздел %s не найден на диске %s", number, disk)
 }
 
 // checkRoot проверяет, запущен ли установщик от имени root
@@ -214,20 +467,38 @@ func checkRoot() {
 }
 
 // checkCommands проверяет наличие необходимых системных команд
-func checkCommands() error {
+func checkCommands(options *InstallOptions) error {
 	commands := []string{
 		"podman",
+		"skopeo",
 		"rsync",
 		"wipefs",
 		"parted",
 		"mkfs.fat",
-		"mkfs.btrfs",
 		"mkfs.ext4",
 		"mount",
 		"umount",
 		"blkid",
 		"lsblk",
+		"mkswap",
+	}
+	if options.EnrollMok {
+		commands = append(commands, "openssl")
+		if options.ToImage == "" {
+			commands = append(commands, "mokutil")
+		}
 	}
+	if options.ToImage != "" {
+		commands = append(commands, "losetup")
+		if options.ImageFormat == "qcow2" {
+			commands = append(commands, "qemu-img")
+		}
+	}
+	return checkCommandsAvailable(commands)
+}
+
+// checkCommandsAvailable проверяет, что все команды из списка есть в PATH
+func checkCommandsAvailable(commands []string) error {
 	for _, cmd := range commands {
 		if _, err := exec.LookPath(cmd); err != nil {
 			return fmt.Errorf("команда %s не найдена в PATH", cmd)
@@ -267,44 +538,67 @@ func unmount(path string) error {
 }
 
 // prepareDisk выполняет подготовку диска
-func prepareDisk(disk string, rootFileSystem string, typeBoot string) error {
+func prepareDisk(config *InstallConfig) error {
+	disk, rootFileSystem, typeBoot := config.Disk, config.Filesystem, config.BootMode
 	paths := []string{"/mnt/target/boot/efi", "/mnt/target/boot", container_dir, "/mnt/target"}
 
 	for _, path := range paths {
 		_ = unmount(path)
 	}
 
-	log.Printf("Подготовка диска %s с файловой системой %s в режиме %s\n", disk, rootFileSystem, typeBoot)
+	log.Printf("Подготовка диска %s с файловой системой %s в режиме %s\n", disk, rootFileSystem.Name(), typeBoot)
+
+	rootFsType := rootFileSystem.Name()
+	diskSize, err := diskSizeMiB(disk)
+	if err != nil {
+		return err
+	}
+
+	// Раздел подкачки располагается в конце диска, чтобы root-раздел мог расшириться до него
+	swapSize := 0
+	if config.Swap.Mode == "partition" {
+		swapSize = config.Swap.SizeMiB
+	}
+
+	tempEnd, err := tempPartitionEnd(diskSize, swapSize)
+	if err != nil {
+		return err
+	}
 
 	// Команды для разметки
 	var commands [][]string
 
-	if typeBoot == "LEGACY" {
+	if usesBiosLayout(typeBoot) {
 		commands = [][]string{
 			{"wipefs", "--all", disk},
 			{"parted", "-s", disk, "mklabel", "gpt"},
-			{"parted", "-s", disk, "mkpart", "primary", "1MiB", "3MiB"},                        // BIOS Boot Partition (2 МиБ)
-			{"parted", "-s", disk, "set", "1", "bios_grub", "on"},                              // BIOS Boot Partition
-			{"parted", "-s", disk, "mkpart", "primary", "fat32", "3MiB", "1003MiB"},            // EFI раздел (1 ГБ)
-			{"parted", "-s", disk, "set", "2", "boot", "on"},                                   // EFI раздел
-			{"parted", "-s", disk, "mkpart", "primary", "ext4", "1003MiB", "3003MiB"},          // Boot раздел (2 ГБ)
-			{"parted", "-s", disk, "mkpart", "primary", rootFileSystem, "3003MiB", "25000MiB"}, // Root раздел
-			{"parted", "-s", disk, "mkpart", "primary", "ext4", "25000MiB", "60000MiB"},        // Временный раздел
+			{"parted", "-s", disk, "mkpart", "primary", "1MiB", "3MiB"},                    // BIOS Boot Partition (2 МиБ)
+			{"parted", "-s", disk, "set", "1", "bios_grub", "on"},                          // BIOS Boot Partition
+			{"parted", "-s", disk, "mkpart", "primary", "fat32", "3MiB", "1003MiB"},        // EFI раздел (1 ГБ)
+			{"parted", "-s", disk, "set", "2", "boot", "on"},                               // EFI раздел
+			{"parted", "-s", disk, "mkpart", "primary", "ext4", "1003MiB", "3003MiB"},      // Boot раздел (2 ГБ)
+			{"parted", "-s", disk, "mkpart", "primary", rootFsType, "3003MiB", "25000MiB"}, // Root раздел
+			{"parted", "-s", disk, "mkpart", "primary", "ext4", "25000MiB", tempEnd},       // Временный раздел
 		}
 	} else if typeBoot == "UEFI" {
 		commands = [][]string{
 			{"wipefs", "--all", disk},
 			{"parted", "-s", disk, "mklabel", "gpt"},
-			{"parted", "-s", disk, "mkpart", "primary", "fat32", "1MiB", "601MiB"},             // EFI раздел (600 МБ)
-			{"parted", "-s", disk, "set", "1", "boot", "on"},                                   // EFI раздел
-			{"parted", "-s", disk, "mkpart", "primary", "ext4", "601MiB", "2601MiB"},           // Boot раздел (2 ГБ)
-			{"parted", "-s", disk, "mkpart", "primary", rootFileSystem, "2601MiB", "25000MiB"}, // Root раздел
-			{"parted", "-s", disk, "mkpart", "primary", "ext4", "25000MiB", "60000MiB"},        // Временный раздел
+			{"parted", "-s", disk, "mkpart", "primary", "fat32", "1MiB", "601MiB"},         // EFI раздел (600 МБ)
+			{"parted", "-s", disk, "set", "1", "boot", "on"},                               // EFI раздел
+			{"parted", "-s", disk, "mkpart", "primary", "ext4", "601MiB", "2601MiB"},       // Boot раздел (2 ГБ)
+			{"parted", "-s", disk, "mkpart", "primary", rootFsType, "2601MiB", "25000MiB"}, // Root раздел
+			{"parted", "-s", disk, "mkpart", "primary", "ext4", "25000MiB", tempEnd},       // Временный раздел
 		}
 	} else {
 		return fmt.Errorf("неизвестный тип загрузки: %s", typeBoot)
 	}
 
+	if swapSize > 0 {
+		swapStart := fmt.Sprintf("%dMiB", diskSize-swapSize-1)
+		commands = append(commands, []string{"parted", "-s", disk, "mkpart", "primary", "linux-swap", swapStart, "100%"})
+	}
+
 	for _, args := range commands {
 		cmd := exec.Command(args[0], args[1:]...)
 		cmd.Stdout = os.Stdout
@@ -313,6 +607,7 @@ func prepareDisk(disk string, rootFileSystem string, typeBoot string) error {
 			return fmt.Errorf("ошибка выполнения команды %s: %v", args[0], err)
 		}
 	}
+	settleDevices()
 
 	partitions, err := getNamedPartitions(disk, typeBoot)
 	if err != nil {
@@ -335,28 +630,16 @@ func prepareDisk(disk string, rootFileSystem string, typeBoot string) error {
 	}{
 		{"mkfs.vfat", []string{"-F32", partitions["efi"].Path}}, // Форматирование EFI раздела
 		{"mkfs.ext4", []string{partitions["boot"].Path}},        // Форматирование boot раздела
+		{"mkfs.ext4", []string{partitions["temp"].Path}},        // Форматирование временного раздела
 	}
 
-	if rootFileSystem == "ext4" {
+	if swapSize > 0 {
 		formats = append(formats, struct {
 			cmd  string
 			args []string
-		}{"mkfs.ext4", []string{partitions["root"].Path}})
-
-	} else if rootFileSystem == "btrfs" {
-		formats = append(formats, struct {
-			cmd  string
-			args []string
-		}{"mkfs.btrfs", []string{"-f", partitions["root"].Path}})
-	} else {
-		return fmt.Errorf("неизвестная файловая система: %s", rootFileSystem)
+		}{"mkswap", []string{partitions["swap"].Path}}) // Форматирование раздела подкачки
 	}
 
-	formats = append(formats, struct {
-		cmd  string
-		args []string
-	}{"mkfs.ext4", []string{partitions["temp"].Path}})
-
 	for _, format := range formats {
 		cmd := exec.Command(format.cmd, format.args...)
 		cmd.Stdout = os.Stdout
@@ -366,10 +649,17 @@ func prepareDisk(disk string, rootFileSystem string, typeBoot string) error {
 		}
 	}
 
-	if rootFileSystem == "btrfs" {
-		if err := createBtrfsSubVolumes(partitions["root"].Path); err != nil {
-			return fmt.Errorf("ошибка создания подтомов Btrfs: %v", err)
+	// Форматирование root раздела драйвером файловой системы
+	if err := rootFileSystem.Format(partitions["root"].Path); err != nil {
+		return err
+	}
+
+	// Хранилище хоста будет скрыто временным разделом, поэтому для containers-storage: оно подключается заранее
+	if strings.HasPrefix(config.Image, "containers-storage:") {
+		if err := bindHostStorage(); err != nil {
+			return err
 		}
+		defer unmount(hostStoragePath)
 	}
 
 	// Создание временного раздела
@@ -386,65 +676,40 @@ func prepareDisk(disk string, rootFileSystem string, typeBoot string) error {
 			return fmt.Errorf("ошибка выполнения команды %s: %v", args[0], err)
 		}
 	}
+	if isLocalImage(config.Image) {
+		if err := loadOfflineImage(config.Image); err != nil {
+			return err
+		}
+	}
+
 	log.Printf("Диск %s успешно подготовлен.\n", disk)
 
 	return nil
 }
 
-func createBtrfsSubVolumes(rootPartition string) error {
-	mountPoint := "/mnt/btrfs-setup"
-	if err := os.MkdirAll(mountPoint, 0755); err != nil {
-		return fmt.Errorf("ошибка создания точки монтирования: %v", err)
-	}
-	defer os.RemoveAll(mountPoint)
-
-	if err := mountDisk(rootPartition, mountPoint, "rw,subvol=/"); err != nil {
-		return fmt.Errorf("ошибка монтирования Btrfs раздела: %v", err)
+// settleDevices ждёт, пока udev создаст устройства разделов после разметки: у loop-устройства они появляются не сразу
+func settleDevices() {
+	if err := exec.Command("udevadm", "settle").Run(); err != nil {
+		log.Printf("Предупреждение: ошибка выполнения udevadm settle: %v\n", err)
 	}
-	defer unmountDisk(mountPoint)
-
-	subVolumes := []string{"@", "@home", "@var"}
-	for _, subVol := range subVolumes {
-		subVolPath := fmt.Sprintf("%s/%s", mountPoint, subVol)
-		if _, err := os.Stat(subVolPath); os.IsNotExist(err) {
-			cmd := exec.Command("btrfs", "subvolume", "create", subVolPath)
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-			if err := cmd.Run(); err != nil {
-				return fmt.Errorf("ошибка создания подтома %s: %v", subVol, err)
-			}
-		} else {
-			log.Printf("Подтом %s уже существует, пропуск.", subVol)
-		}
-	}
-
-	return nil
 }
 
 // installToFilesystem выполняет установку с использованием bootc
-func installToFilesystem(image string, disk string, typeBoot string, rootFileSystem string, user *UserCreation) error {
+func installToFilesystem(config *InstallConfig) error {
+	rootFileSystem, user := config.Filesystem, config.User
 	mountPoint := "/mnt/target"
-	mountBtrfsVar := "/mnt/btrfs/var"
-	mountBtrfsHome := "/mnt/btrfs/home"
 	mountPointBoot := "/mnt/target/boot"
 	efiMountPoint := "/mnt/target/boot/efi"
-	var installCmd string
 
 	// Получаем именованные разделы
-	partitions, err := getNamedPartitions(disk, typeBoot)
+	partitions, err := getNamedPartitions(config.Disk, config.BootMode)
 	if err != nil {
 		return fmt.Errorf("ошибка получения разделов: %v", err)
 	}
 
 	// Монтируем разделы
-	if rootFileSystem == "btrfs" {
-		if err := mountDisk(partitions["root"].Path, mountPoint, "subvol=@"); err != nil {
-			return fmt.Errorf("ошибка монтирования корневого подтома: %v", err)
-		}
-	} else {
-		if err := mountDisk(partitions["root"].Path, mountPoint, ""); err != nil {
-			return fmt.Errorf("ошибка монтирования root раздела: %v", err)
-		}
+	if err := mountDisk(partitions["root"].Path, mountPoint, rootFileSystem.RootMountOptions()); err != nil {
+		return fmt.Errorf("ошибка монтирования root раздела: %v", err)
 	}
 
 	if err := mountDisk(partitions["boot"].Path, mountPointBoot, ""); err != nil {
@@ -456,27 +721,33 @@ func installToFilesystem(image string, disk string, typeBoot string, rootFileSys
 	}
 
 	// Выполняем установку с использованием bootc
-	if typeBoot == "UEFI" {
-		installCmd = fmt.Sprintf(
-			"[ -f /usr/libexec/init-ostree.sh ] && /usr/libexec/init-ostree.sh; bootc install to-filesystem --skip-fetch-check --disable-selinux %s",
-			"/mnt/target",
-		)
-	} else {
-		installCmd = fmt.Sprintf(
-			"[ -f /usr/libexec/init-ostree.sh ] && /usr/libexec/init-ostree.sh; bootc install to-filesystem --skip-fetch-check --generic-image --disable-selinux %s",
-			"/mnt/target",
-		)
+	bootcArgs := []string{"--skip-fetch-check"}
+	if config.BootMode != "UEFI" || config.GenericImage {
+		bootcArgs = append(bootcArgs, "--generic-image")
+	}
+	bootcArgs = append(bootcArgs, "--disable-selinux")
+	// Обновления установленной системы должны идти по тегу из реестра, а не по закреплённому дайджесту
+	// или временному имени offlineImageName
+	bootcArgs = append(bootcArgs, "--target-imgref="+config.TargetImgref)
+	for _, karg := range kernelArguments(config, partitions) {
+		bootcArgs = append(bootcArgs, "--karg="+karg)
 	}
+	bootcArgs = append(bootcArgs, "/mnt/target")
 
-	cmd := exec.Command("podman", "run", "--rm", "--privileged", "--pid=host",
+	// Аргументы bootc передаются через "$@", чтобы не экранировать их для shell
+	installCmd := "[ -f /usr/libexec/init-ostree.sh ] && /usr/libexec/init-ostree.sh; exec bootc install to-filesystem \"$@\""
+
+	// Образ заранее загружен во временное хранилище (pullImage или loadOfflineImage), обращение к реестру не нужно
+	podmanArgs := []string{"run", "--rm", "--privileged", "--pid=host", "--pull=never",
 		"--security-opt", "label=type:unconfined_t",
-		"-v", container_dir+":/var/lib/containers",
+		"-v", container_dir + ":/var/lib/containers",
 		"-v", "/dev:/dev",
 		"-v", "/mnt/target:/mnt/target",
 		"--security-opt", "label=disable",
-		image,
-		"sh", "-c", installCmd,
-	)
+		offlineImageName,
+		"sh", "-c", installCmd, "sh",
+	}
+	cmd := exec.Command("podman", append(podmanArgs, bootcArgs...)...)
 
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -486,99 +757,56 @@ func installToFilesystem(image string, disk string, typeBoot string, rootFileSys
 		return fmt.Errorf("ошибка выполнения bootc: %v", err)
 	}
 
-	unmountDisk(efiMountPoint)
-	unmountDisk(mountPointBoot)
-	unmountDisk(mountPoint)
-
-	if rootFileSystem == "btrfs" {
-		if err := mountDisk(partitions["root"].Path, mountPoint, "rw,subvol=@"); err != nil {
-			return fmt.Errorf("ошибка повторного монтирования корневого подтома: %v", err)
-		}
-
-		if err := mountDisk(partitions["root"].Path, mountBtrfsVar, "subvol=@var"); err != nil {
-			return fmt.Errorf("ошибка монтирования подтома @var: %v", err)
-		}
-
-		if err := mountDisk(partitions["root"].Path, mountBtrfsHome, "subvol=@home"); err != nil {
-			return fmt.Errorf("ошибка монтирования подтома @home: %v", err)
-		}
-
-		ostreeDeployPath, err := findOstreeDeployPath(mountPoint)
-		if err != nil {
-			return fmt.Errorf("ошибка поиска ostree deploy пути: %v", err)
-		}
-
-		if err := configureUserAndRoot(ostreeDeployPath, user.Username, user.Password); err != nil {
-			return fmt.Errorf("ошибка настройки пользователя и root: %v", err)
-		}
-
-		if err := configureTimezone(ostreeDeployPath, timezone); err != nil {
-			return fmt.Errorf("ошибка установки timezone: %v", err)
-		}
-
-		// Копируем содержимое /var в подтом @var
-		if err := copyWithRsync(fmt.Sprintf("%s/var/", ostreeDeployPath), mountBtrfsVar); err != nil {
-			return fmt.Errorf("ошибка копирования /var в @var: %v", err)
-		}
-
-		// Копируем содержимое /home в подтом @home
-		if err := copyWithRsync(fmt.Sprintf("%s/home/", ostreeDeployPath), mountBtrfsHome); err != nil {
-			return fmt.Errorf("ошибка копирования /home в @home: %v", err)
-		}
-
-		//Очищаем содержимое /var внутри ostree
-		if err := clearDirectory(fmt.Sprintf("%s/var", ostreeDeployPath)); err != nil {
-			return fmt.Errorf("ошибка очистки содержимого /var: %v", err)
+	for _, point := range []string{efiMountPoint, mountPointBoot, mountPoint} {
+		if err := unmountDisk(point); err != nil {
+			return err
 		}
+	}
 
-		//путь к папке var
-		varDeployPath := fmt.Sprintf("%s/var", filepath.Join(ostreeDeployPath, "../../"))
+	if err := mountDisk(partitions["root"].Path, mountPoint, joinMountOptions("rw", rootFileSystem.RootMountOptions())); err != nil {
+		return fmt.Errorf("ошибка повторного монтирования root раздела: %v", err)
+	}
 
-		//Очищаем содержимое ostree/deploy/default/var
-		if err := clearDirectory(varDeployPath); err != nil {
-			return fmt.Errorf("ошибка очистки содержимого /ostree/deploy/default/var: %v", err)
-		}
+	ostreeDeployPath, err := findOstreeDeployPath(mountPoint)
+	if err != nil {
+		return fmt.Errorf("ошибка поиска ostree deploy пути: %v", err)
+	}
 
-		selabeledFilePath := fmt.Sprintf("%s/.ostree-selabeled", varDeployPath)
-		log.Printf("Создание файла %s\n", selabeledFilePath)
+	if err := configureUserAndRoot(ostreeDeployPath, user.Username, user.Password); err != nil {
+		return fmt.Errorf("ошибка настройки пользователя и root: %v", err)
+	}
 
-		file, err := os.Create(selabeledFilePath)
-		if err != nil {
-			return fmt.Errorf("ошибка создания файла .ostree-selabeled: %v", err)
-		}
+	if err := configureTimezone(ostreeDeployPath, timezone); err != nil {
+		return fmt.Errorf("ошибка установки timezone: %v", err)
+	}
 
-		errFile := file.Close()
-		if errFile != nil {
-			return fmt.Errorf("ошибка очистки содержимого /ostree/deploy/default/var: %v", errFile)
-		}
-	} else {
-		if err := mountDisk(partitions["root"].Path, mountPoint, "rw"); err != nil {
-			return fmt.Errorf("ошибка повторного монтирования root раздела: %v", err)
-		}
+	if err := writeInstallManifest(config, ostreeDeployPath); err != nil {
+		return err
+	}
 
-		ostreeDeployPath, err := findOstreeDeployPath(mountPoint)
-		if err != nil {
-			return fmt.Errorf("ошибка поиска ostree deploy пути: %v", err)
-		}
+	if err := configureTargetRegistries(config.Registry, ostreeDeployPath); err != nil {
+		return fmt.Errorf("ошибка настройки доступа к реестрам: %v", err)
+	}
 
-		if err := configureUserAndRoot(ostreeDeployPath, user.Username, user.Password); err != nil {
-			return fmt.Errorf("ошибка настройки пользователя и root: %v", err)
+	if config.EnrollMok {
+		if err := configureMok(config, ostreeDeployPath); err != nil {
+			return err
 		}
+	}
 
-		varDeployPath := filepath.Join(ostreeDeployPath, "../../var/home")
-
-		// Копируем содержимое /home из коммита внутрь varDeployPath
-		if err := copyWithRsync(fmt.Sprintf("%s/home/", ostreeDeployPath), varDeployPath); err != nil {
-			return fmt.Errorf("ошибка копирования /home в @home: %v", err)
-		}
+	// Переносим /var и /home в постоянное хранилище файловой системы
+	if err := rootFileSystem.PopulateState(partitions["root"].Path, ostreeDeployPath); err != nil {
+		return err
+	}
 
-		// Очищаем содержимое /var внутри ostree
-		if err := clearDirectory(fmt.Sprintf("%s/var", ostreeDeployPath)); err != nil {
-			return fmt.Errorf("ошибка очистки содержимого /var: %v", err)
-		}
+	if err := configureSwap(config.Swap, rootFileSystem, partitions["root"].Path, ostreeDeployPath); err != nil {
+		return fmt.Errorf("ошибка настройки подкачки: %v", err)
+	}
 
-		if err := configureTimezone(ostreeDeployPath, timezone); err != nil {
-			return fmt.Errorf("ошибка установки timezone: %v", err)
+	// Заводские снимки делаются последними, когда данные пользователя уже перенесены
+	if btrfs, ok := rootFileSystem.(*btrfsDriver); ok {
+		if err := btrfs.finalizeSnapshots(partitions["root"].Path, ostreeDeployPath); err != nil {
+			return fmt.Errorf("ошибка создания снимков btrfs: %v", err)
 		}
 	}
 
@@ -590,21 +818,54 @@ func installToFilesystem(image string, disk string, typeBoot string, rootFileSys
 		return fmt.Errorf("ошибка повторного монтирования EFI раздела: %v", err)
 	}
 
+	// Гибридный диск должен загружаться на прошивке без записи в NVRAM, поэтому нужен EFI/BOOT/BOOTX64.EFI
+	if config.BootMode == "HYBRID" {
+		if err := ensureEfiFallback(efiMountPoint); err != nil {
+			return fmt.Errorf("ошибка установки резервного EFI загрузчика: %v", err)
+		}
+	}
+
 	// Генерация fstab
 	log.Println("Генерация fstab...")
-	if err := generateFstab(mountPoint, partitions, rootFileSystem); err != nil {
+	if err := generateFstab(mountPoint, partitions, config); err != nil {
 		return fmt.Errorf("ошибка генерации fstab: %v", err)
 	}
 
-	unmountDisk(efiMountPoint)
-	unmountDisk(mountPointBoot)
-	if rootFileSystem == "btrfs" {
-		unmountDisk(mountBtrfsHome)
-		unmountDisk(mountBtrfsVar)
+	if err := unmountDisk(efiMountPoint); err != nil {
+		return err
+	}
+	if err := unmountDisk(mountPointBoot); err != nil {
+		return err
 	}
 	time.Sleep(5 * time.Second)
-	unmountDisk(mountPoint)
-	return nil
+	return unmountDisk(mountPoint)
+}
+
+// configureMok создаёт ключ MOK и регистрирует его в NVRAM, если установка идёт на эту же машину с UEFI
+func configureMok(config *InstallConfig, ostreeDeployPath string) error {
+	if err := generateMokKey(ostreeDeployPath); err != nil {
+		return err
+	}
+
+	if !needsMokEnrollment(config) {
+		log.Printf("Ключ MOK создан, зарегистрируйте его в установленной системе: mokutil --import %s\n", mokCertificatePath)
+		return nil
+	}
+	return enrollMokKey(ostreeDeployPath, config.MokPassword)
+}
+
+// needsMokEnrollment сообщает, регистрируется ли ключ MOK в NVRAM: только при установке на эту же машину с UEFI
+func needsMokEnrollment(config *InstallConfig) bool {
+	return config.EnrollMok && !config.GenericImage && config.BootMode != "LEGACY" && readSecureBootState().Supported
+}
+
+// kernelArguments возвращает аргументы ядра для установленной системы
+func kernelArguments(config *InstallConfig, partitions map[string]PartitionInfo) []string {
+	var kargs []string
+	if config.Swap.Mode == "partition" && config.Swap.Hibernate {
+		kargs = append(kargs, "resume=UUID="+getUUID(partitions["swap"].Path))
+	}
+	return append(kargs, config.KernelArgs...)
 }
 
 // configureTimezone устанавливает тайм-зону в указанном chroot окружении
@@ -703,9 +964,13 @@ func clearDirectory(path string) error {
 	return nil
 }
 
-// copyWithRsync копирование с использованием команды rsync
-func copyWithRsync(src string, dst string) error {
-	cmd := exec.Command("rsync", "-aHAX", src, dst)
+// copyWithRsync копирование с использованием команды rsync, excludes — шаблоны rsync --exclude
+func copyWithRsync(src string, dst string, excludes ...string) error {
+	args := []string{"-aHAX"}
+	for _, exclude := range excludes {
+		args = append(args, "--exclude="+exclude)
+	}
+	cmd := exec.Command("rsync", append(args, src, dst)...)
 	cmd.Stdout = nil
 	cmd.Stderr = nil
 
@@ -733,7 +998,7 @@ func findOstreeDeployPath(mountPoint string) (string, error) {
 	return "", fmt.Errorf("не найдена папка, в %s", deployPath)
 }
 
-func generateFstab(mountPoint string, partitions map[string]PartitionInfo, rootFileSystem string) error {
+func generateFstab(mountPoint string, partitions map[string]PartitionInfo, config *InstallConfig) error {
 	ostreeDeployPath, err := findOstreeDeployPath(mountPoint)
 	if err != nil {
 		return fmt.Errorf("ошибка поиска ostree deploy пути: %v", err)
@@ -744,26 +1009,8 @@ func generateFstab(mountPoint string, partitions map[string]PartitionInfo, rootF
 
 	fstabContent := "# Auto generate fstab from atomic-actions installer \n"
 
-	if rootFileSystem == "btrfs" {
-		fstabContent += fmt.Sprintf(
-			"UUID=%s / btrfs subvol=@,compress=zstd:1,x-systemd.device-timeout=0 0 0\n",
-			getUUID(partitions["root"].Path),
-		)
-		fstabContent += fmt.Sprintf(
-			"UUID=%s /home btrfs subvol=@home,compress=zstd:1,x-systemd.device-timeout=0 0 0\n",
-			getUUID(partitions["root"].Path),
-		)
-		fstabContent += fmt.Sprintf(
-			"UUID=%s /var btrfs subvol=@var,compress=zstd:1,x-systemd.device-timeout=0 0 0\n",
-			getUUID(partitions["root"].Path),
-		)
-	} else if rootFileSystem == "ext4" {
-		fstabContent += fmt.Sprintf(
-			"UUID=%s / ext4 defaults 1 1\n",
-			getUUID(partitions["root"].Path),
-		)
-	} else {
-		return fmt.Errorf("неизвестная файловая система: %s", rootFileSystem)
+	for _, entry := range config.Filesystem.FstabEntries(getUUID(partitions["root"].Path)) {
+		fstabContent += entry + "\n"
 	}
 
 	fstabContent += fmt.Sprintf(
@@ -775,6 +1022,13 @@ func generateFstab(mountPoint string, partitions map[string]PartitionInfo, rootF
 		getUUID(partitions["efi"].Path),
 	)
 
+	switch config.Swap.Mode {
+	case "partition":
+		fstabContent += fmt.Sprintf("UUID=%s none swap defaults 0 0\n", getUUID(partitions["swap"].Path))
+	case "file":
+		fstabContent += fmt.Sprintf("%s none swap defaults 0 0\n", swapFilePath)
+	}
+
 	file, err := os.Create(fstabPath)
 	if err != nil {
 		return fmt.Errorf("ошибка создания %s: %v", fstabPath, err)
@@ -805,31 +1059,100 @@ func getNamedPartitions(disk string, typeBoot string) (map[string]PartitionInfo,
 	for i, partition := range partitions {
 		fmt.Printf("Раздел %d: %s\n", i+1, partition)
 	}
-	if typeBoot == "LEGACY" && len(partitions) < 4 {
-		return nil, fmt.Errorf("недостаточно разделов на диске для режима LEGACY")
+	if usesBiosLayout(typeBoot) && len(partitions) < 4 {
+		return nil, fmt.Errorf("недостаточно разделов на диске для режима %s", typeBoot)
 	} else if typeBoot == "UEFI" && len(partitions) < 3 {
 		return nil, fmt.Errorf("недостаточно разделов на диске для режима UEFI")
 	}
 
+	// Номера разделов в разметке, временный раздел после установки удаляется, поэтому
+	// сопоставляем по номеру раздела в имени устройства, а не по позиции в списке
+	var layout map[string]string
+	if usesBiosLayout(typeBoot) {
+		layout = map[string]string{
+			"bios": "1", // BIOS Boot Partition
+			"efi":  "2", // EFI Partition
+			"boot": "3", // Boot Partition
+			"root": "4", // Root Partition
+			"temp": "5", // Temporary Partition
+			"swap": "6", // Swap Partition
+		}
+	} else if typeBoot == "UEFI" {
+		layout = map[string]string{
+			"efi":  "1", // EFI Partition
+			"boot": "2", // Boot Partition
+			"root": "3", // Root Partition
+			"temp": "4", // Temporary Partition
+			"swap": "5", // Swap Partition
+		}
+	}
+
+	byNumber := make(map[string]string)
+	for _, partition := range partitions {
+		byNumber[partitionNumber(partition)] = partition
+	}
+
 	// Карта с информацией о разделах
 	namedPartitions := make(map[string]PartitionInfo)
-
-	if typeBoot == "LEGACY" {
-		namedPartitions["bios"] = PartitionInfo{Path: partitions[0], Number: "1"} // BIOS Boot Partition
-		namedPartitions["efi"] = PartitionInfo{Path: partitions[1], Number: "2"}  // EFI Partition
-		namedPartitions["boot"] = PartitionInfo{Path: partitions[2], Number: "3"} // Boot Partition
-		namedPartitions["root"] = PartitionInfo{Path: partitions[3], Number: "4"} // Root Partition
-		namedPartitions["temp"] = PartitionInfo{Path: partitions[4], Number: "5"} // Temporary Partition
-	} else if typeBoot == "UEFI" {
-		namedPartitions["efi"] = PartitionInfo{Path: partitions[0], Number: "1"}  // EFI Partition
-		namedPartitions["boot"] = PartitionInfo{Path: partitions[1], Number: "2"} // Boot Partition
-		namedPartitions["root"] = PartitionInfo{Path: partitions[2], Number: "3"} // Root Partition
-		namedPartitions["temp"] = PartitionInfo{Path: partitions[3], Number: "4"} // Temporary Partition
+	for name, number := range layout {
+		if path, exists := byNumber[number]; exists {
+			namedPartitions[name] = PartitionInfo{Path: path, Number: number}
+		}
 	}
 
 	return namedPartitions, nil
 }
 
+// partitionNumber возвращает номер раздела из имени устройства: /dev/sda3, /dev/nvme0n1p3, /dev/loop0p3
+func partitionNumber(partition string) string {
+	end := len(partition)
+	start := end
+	for start > 0 && partition[start-1] >= '0' && partition[start-1] <= '9' {
+		start--
+	}
+	return partition[start:end]
+}
+
+// diskSizeMiB возвращает размер диска в МиБ
+func diskSizeMiB(disk string) (int, error) {
+	output, err := exec.Command("lsblk", "-b", "-d", "-n", "-o", "SIZE", disk).Output()
+	if err != nil {
+		return 0, fmt.Errorf("ошибка получения размера диска %s: %v", disk, err)
+	}
+
+	size, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка разбора размера диска %s: %v", disk, err)
+	}
+
+	return int(size >> 20), nil
+}
+
+// minLayoutMiB место под разметку установщика: root-раздел до 25000MiB и временный раздел не меньше 10 ГиБ
+const minLayoutMiB = 25000 + 10240
+
+// swapSpaceMiB возвращает место на диске, которое останется под раздел подкачки после разметки установщика
+func swapSpaceMiB(diskSize int) int {
+	return max(diskSize-1-minLayoutMiB, 0)
+}
+
+// tempPartitionEnd возвращает границу временного раздела: 60000MiB, а на дисках меньшего
+// размера (файлы образов) — до конца диска за вычетом места, зарезервированного под подкачку
+func tempPartitionEnd(diskSize int, reserved int) (string, error) {
+	end := diskSize - reserved - 1
+	if end < minLayoutMiB {
+		return "", fmt.Errorf("недостаточно места на диске: %d МиБ, из них %d МиБ под подкачку", diskSize, reserved)
+	}
+
+	if end >= 60000 {
+		return "60000MiB", nil
+	}
+	if reserved == 0 {
+		return "100%", nil
+	}
+	return fmt.Sprintf("%dMiB", end), nil
+}
+
 // getPartitionNames возвращает список всех разделов на указанном диске
 func getPartitions(disk string) ([]string, error) {
 	cmd := exec.Command("lsblk", "-ln", "-o", "NAME,TYPE", disk)
@@ -870,12 +1193,53 @@ func mountDisk(disk string, mountPoint string, options string) error {
 	return nil
 }
 
+// runCommand запускает команду с выводом в консоль
+func runCommand(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
 // unmountDisk размонтирует указанную точку монтирования
-func unmountDisk(mountPoint string) {
+func unmountDisk(mountPoint string) error {
 	log.Printf("Размонтирование %s...\n", mountPoint)
 	cmd := exec.Command("umount", mountPoint)
 	if err := cmd.Run(); err != nil {
-		log.Printf("Ошибка размонтирования %s: %v\n", mountPoint, err.Error())
+		return fmt.Errorf("ошибка размонтирования %s: %v", mountPoint, err)
+	}
+	return nil
+}
+
+// unmountOrWarn размонтирует точку монтирования в отложенной очистке, ошибка только выводится
+func unmountOrWarn(mountPoint string) {
+	if err := unmountDisk(mountPoint); err != nil {
+		log.Printf("Предупреждение: %v\n", err)
+	}
+}
+
+// releaseMountPoint размонтирует временную точку монтирования и удаляет её пустой каталог.
+// Каталог удаляется только после успешного размонтирования: os.RemoveAll на смонтированном
+// разделе удалил бы его содержимое
+func releaseMountPoint(mountPoint string) error {
+	if err := unmountDisk(mountPoint); err != nil {
+		return err
+	}
+	if err := os.Remove(mountPoint); err != nil {
+		return fmt.Errorf("ошибка удаления точки монтирования %s: %v", mountPoint, err)
+	}
+	return nil
+}
+
+// releaseMountPointInto освобождает точку монтирования в отложенной очистке и возвращает ошибку
+// через результат функции, если основная работа завершилась успешно
+func releaseMountPointInto(err *error, mountPoint string) {
+	if releaseErr := releaseMountPoint(mountPoint); releaseErr != nil {
+		if *err == nil {
+			*err = releaseErr
+		} else {
+			log.Printf("Предупреждение: %v\n", releaseErr)
+		}
 	}
 }
 
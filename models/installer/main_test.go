@@ -0,0 +1,107 @@
+package installer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTempPartitionEnd(t *testing.T) {
+	tests := []struct {
+		name     string
+		diskSize int // МиБ
+		reserved int // МиБ под раздел подкачки
+		want     string
+		wantErr  bool
+	}{
+		{name: "большой диск", diskSize: 500 * 1024, want: "60000MiB"},
+		{name: "большой диск с подкачкой", diskSize: 500 * 1024, reserved: 16384, want: "60000MiB"},
+		{name: "граница 60000MiB", diskSize: 60001, want: "60000MiB"},
+		{name: "файл образа 40G без подкачки", diskSize: 40 * 1024, want: "100%"},
+		{name: "файл образа 40G с подкачкой 4G", diskSize: 40 * 1024, reserved: 4096, want: "36863MiB"},
+		{name: "подкачка не помещается на файл образа 40G", diskSize: 40 * 1024, reserved: 8192, wantErr: true},
+		{name: "минимальный размер", diskSize: 25000 + 10240 + 1, want: "100%"},
+		{name: "диск меньше минимального", diskSize: 25000 + 10240, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tempPartitionEnd(tt.diskSize, tt.reserved)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ошибка = %v, ожидалась ошибка: %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("tempPartitionEnd(%d, %d) = %q, ожидалось %q", tt.diskSize, tt.reserved, got, tt.want)
+			}
+		})
+	}
+}
+
+// Подкачка по рекомендации для машины с 4 ГБ памяти на файле образа минимального размера
+func TestTempPartitionEndRecommendedSwap(t *testing.T) {
+	swap := recommendedSwapMiB(4096, false)
+	if _, err := tempPartitionEnd(36*1024, swap); err == nil || !strings.Contains(err.Error(), "под подкачку") {
+		t.Fatalf("ожидалась ошибка о месте под подкачку, получено %v", err)
+	}
+
+	got, err := tempPartitionEnd(40*1024, swap)
+	if err != nil {
+		t.Fatalf("неожиданная ошибка: %v", err)
+	}
+	if want := "36863MiB"; got != want {
+		t.Errorf("tempPartitionEnd() = %q, ожидалось %q", got, want)
+	}
+}
+
+func TestPartitionNumber(t *testing.T) {
+	tests := []struct {
+		partition string
+		want      string
+	}{
+		{"/dev/sda1", "1"},
+		{"/dev/sda12", "12"},
+		{"/dev/nvme0n1p3", "3"},
+		{"/dev/mmcblk0p2", "2"},
+		{"/dev/loop0p4", "4"},
+		{"/dev/sda", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.partition, func(t *testing.T) {
+			if got := partitionNumber(tt.partition); got != tt.want {
+				t.Errorf("partitionNumber(%q) = %q, ожидалось %q", tt.partition, got, tt.want)
+			}
+		})
+	}
+}
+
+// Систему, которую не удалось проверить, нельзя считать успешно установленной, отчёт при этом сохраняется
+func TestCheckInstallationNotVerified(t *testing.T) {
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+	config := &InstallConfig{
+		Image:      "ghcr.io/alt-gnome/alt-atomic:latest",
+		Disk:       "/dev/atomic-actions-test-missing",
+		BootMode:   "UEFI",
+		Filesystem: &ext4Driver{},
+		ReportPath: reportPath,
+	}
+
+	if checkInstallation(config) {
+		t.Fatal("непроверенная установка считается успешной")
+	}
+
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("отчёт не сохранён: %v", err)
+	}
+	var report VerificationReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Passed || len(report.Checks) != 1 || report.Checks[0].Name != "setup" || report.Checks[0].Passed {
+		t.Errorf("отчёт = %+v, ожидалась одна проваленная проверка setup", report)
+	}
+}
@@ -0,0 +1,166 @@
+package installer
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// stringList значение флага, который можно указать несколько раз
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// InstallOptions параметры установки, переданные в командной строке install-system
+type InstallOptions struct {
+	ToImage     string // Путь к файлу образа диска вместо физического диска
+	ImageSize   string // Размер создаваемого файла образа
+	ImageFormat string // Формат файла образа: raw или qcow2
+
+	BtrfsProfile string // Путь к JSON-профилю подтомов и опций монтирования btrfs
+
+	Swap      string // Режим подкачки: none, partition, file или zram; пусто — выбор на шаге установщика
+	SwapSize  string // Размер раздела или файла подкачки, по умолчанию рассчитывается по объёму памяти
+	Hibernate bool   // Раздел подкачки под гибернацию с аргументом ядра resume=
+
+	KernelArgs stringList // Дополнительные аргументы ядра; если заданы, шаг ввода аргументов пропускается
+
+	EnrollMok bool // Создать ключ MOK для подписи модулей ядра и зарегистрировать его через mokutil
+
+	KeepBootOrder bool // Не ставить созданную загрузочную запись UEFI первой в BootOrder
+
+	ReportPath string // Путь для JSON-отчёта проверки установленной системы
+
+	RequireSigned bool // Отказаться от установки образа без проверенной подписи
+
+	TargetImgref string // Образ в реестре, из которого обновляется система, установленная из локального архива
+
+	AuthFile           string     // Учётные данные реестров в формате containers-auth.json
+	RegistryMirrors    stringList // Зеркала реестров вида реестр=зеркало
+	InsecureRegistries stringList // Реестры и зеркала без TLS
+}
+
+// InstallConfig параметры установки, выбранные на шагах установщика
+type InstallConfig struct {
+	Image         string           // Образ контейнера
+	TargetImgref  string           // Образ в реестре, из которого установленная система обновляется через bootc upgrade
+	ImageDigest   string           // Дайджест образа на момент выбора, установка выполняется по нему
+	Disk          string           // Блочное устройство для установки
+	Filesystem    FilesystemDriver // Файловая система root-раздела
+	BootMode      string           // Тип загрузки: UEFI, LEGACY или HYBRID
+	Swap          SwapConfig       // Способ подкачки
+	User          *UserCreation    // Создаваемый пользователь
+	Registry      RegistryConfig   // Учётные данные и зеркала реестров, переносятся в установленную систему
+	KernelArgs    []string         // Дополнительные аргументы ядра, заданные пользователем
+	EnrollMok     bool             // Создать и зарегистрировать ключ MOK
+	MokPassword   string           // Одноразовый пароль подтверждения ключа MOK в MokManager
+	KeepBootOrder bool             // Сохранить текущий порядок загрузки UEFI
+	ReportPath    string           // Путь для JSON-отчёта проверки, пусто — временный каталог
+	GenericImage  bool             // Установка без привязки к текущей машине (файлы образов дисков)
+}
+
+// parseInstallOptions разбирает аргументы команды install-system
+func parseInstallOptions(args []string) (*InstallOptions, error) {
+	options := &InstallOptions{}
+
+	flags := flag.NewFlagSet("install-system", flag.ContinueOnError)
+	flags.StringVar(&options.ToImage, "to-image", "", "Установить в файл образа диска (raw или qcow2) вместо физического диска")
+	flags.StringVar(&options.ImageSize, "size", "60G", "Размер файла образа диска, например 40G")
+	flags.StringVar(&options.ImageFormat, "format", "", "Формат файла образа: raw или qcow2 (по умолчанию определяется по расширению)")
+	flags.StringVar(&options.BtrfsProfile, "btrfs-profile", "", "JSON-файл с подтомами и опциями монтирования btrfs")
+	flags.StringVar(&options.Swap, "swap", "", "Подкачка: none, partition, file или zram")
+	flags.StringVar(&options.SwapSize, "swap-size", "", "Размер раздела или файла подкачки, например 8G")
+	flags.BoolVar(&options.Hibernate, "hibernate", false, "Раздел подкачки с запасом под гибернацию")
+	flags.BoolVar(&options.EnrollMok, "enroll-mok", false, "Создать ключ MOK для подписи модулей ядра (DKMS) и зарегистрировать его при Secure Boot")
+	flags.BoolVar(&options.KeepBootOrder, "keep-boot-order", false, "Не менять порядок загрузки UEFI, только добавить запись")
+	flags.StringVar(&options.ReportPath, "report", "", "Путь для JSON-отчёта проверки установленной системы")
+	flags.BoolVar(&options.RequireSigned, "require-signed", false, "Устанавливать только образы с подписью, проверенной по "+containersPolicyPath)
+	flags.StringVar(&options.TargetImgref, "target-imgref", "", "Образ в реестре для обновлений системы, установленной из локального архива, например ghcr.io/user/image:latest")
+	flags.StringVar(&options.AuthFile, "authfile", "", "Файл учётных данных реестров (auth.json) для skopeo, podman и обновлений bootc")
+	flags.Var(&options.RegistryMirrors, "registry-mirror", "Зеркало реестра вида ghcr.io=mirror.local:5000, можно указать несколько раз")
+	flags.Var(&options.InsecureRegistries, "insecure-registry", "Реестр или зеркало без TLS, можно указать несколько раз")
+	flags.Var(&options.KernelArgs, "karg", "Дополнительный аргумент ядра, можно указать несколько раз, например --karg=nomodeset")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("неизвестные аргументы: %v", flags.Args())
+	}
+
+	var kargs []string
+	for _, value := range options.KernelArgs {
+		kargs = append(kargs, parseKernelArgs(value)...)
+	}
+	options.KernelArgs = kargs
+	if err := validateKernelArgs(options.KernelArgs); err != nil {
+		return nil, fmt.Errorf("ошибка в аргументах ядра: %v", err)
+	}
+
+	if options.ToImage != "" {
+		if options.ImageFormat == "" {
+			options.ImageFormat = imageFormatFromPath(options.ToImage)
+		}
+		if options.ImageFormat != "raw" && options.ImageFormat != "qcow2" {
+			return nil, fmt.Errorf("неизвестный формат образа: %s", options.ImageFormat)
+		}
+	}
+
+	return options, nil
+}
+
+// registryFromOptions возвращает доступ к реестрам, заданный в командной строке
+func registryFromOptions(options *InstallOptions) (RegistryConfig, error) {
+	registry := RegistryConfig{AuthFile: options.AuthFile, Insecure: options.InsecureRegistries}
+	for _, value := range options.RegistryMirrors {
+		source, mirror, err := parseMirror(value)
+		if err != nil {
+			return registry, err
+		}
+		if registry.Mirrors == nil {
+			registry.Mirrors = make(map[string]string)
+		}
+		registry.Mirrors[source] = mirror
+	}
+	return registry, nil
+}
+
+// swapFromOptions возвращает подкачку, заданную в командной строке, или nil, если её нужно выбрать на шаге
+func swapFromOptions(options *InstallOptions) (*SwapConfig, error) {
+	if options.Swap == "" {
+		if options.SwapSize != "" || options.Hibernate {
+			return nil, fmt.Errorf("--swap-size и --hibernate требуют указания --swap")
+		}
+		return nil, nil
+	}
+
+	swap := &SwapConfig{Mode: options.Swap, Hibernate: options.Hibernate}
+	if swap.Mode == "partition" || swap.Mode == "file" {
+		if options.SwapSize != "" {
+			sizeGb, err := parseSize(options.SwapSize)
+			if err != nil {
+				return nil, fmt.Errorf("ошибка разбора размера подкачки: %v", err)
+			}
+			swap.SizeMiB = int(sizeGb * 1024)
+		} else {
+			memoryMiB, err := getMemoryMiB()
+			if err != nil {
+				return nil, err
+			}
+			swap.SizeMiB = recommendedSwapMiB(memoryMiB, swap.Hibernate)
+		}
+	}
+
+	if err := validateSwapConfig(*swap); err != nil {
+		return nil, err
+	}
+	return swap, nil
+}
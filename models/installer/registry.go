@@ -0,0 +1,227 @@
+package installer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// registryLoginAuthFile файл учётных данных, создаваемый при входе в реестр из установщика
+const registryLoginAuthFile = "/run/atomic-actions/auth.json"
+
+// registriesRuntimeConf зеркала для skopeo и podman на время установки, настройки системы установщика не меняются
+const registriesRuntimeConf = "/run/atomic-actions/registries.conf"
+
+// hostRegistriesConf основной registries.conf системы установщика, CONTAINERS_REGISTRIES_CONF заменяет только его,
+// drop-in файлы из registries.conf.d по-прежнему читаются
+const hostRegistriesConf = "/etc/containers/registries.conf"
+
+// registriesDropInPath файл с зеркалами и небезопасными реестрами, относительно корня системы
+const registriesDropInPath = "/etc/containers/registries.conf.d/50-atomic-actions.conf"
+
+// targetAuthFilePath учётные данные, которые bootc использует для обновлений установленной системы
+const targetAuthFilePath = "/etc/ostree/auth.json"
+
+// registryAuthFile учётные данные реестров для skopeo, пусто — используются стандартные
+var registryAuthFile = ""
+
+// RegistryConfig доступ к реестрам образов
+type RegistryConfig struct {
+	AuthFile string            // Файл учётных данных в формате containers-auth.json
+	Mirrors  map[string]string // Зеркало для реестра: ghcr.io -> mirror.local:5000
+	Insecure []string          // Реестры без TLS
+}
+
+// skopeoCommand создаёт команду skopeo с учётными данными установщика
+func skopeoCommand(subcommand string, args ...string) *exec.Cmd {
+	if registryAuthFile != "" {
+		args = append([]string{"--authfile", registryAuthFile}, args...)
+	}
+	return exec.Command("skopeo", append([]string{subcommand}, args...)...)
+}
+
+// registryLogin входит в реестр и сохраняет токен в registryLoginAuthFile
+func registryLogin(registry string, username string, password string) error {
+	if err := os.MkdirAll(filepath.Dir(registryLoginAuthFile), 0700); err != nil {
+		return fmt.Errorf("ошибка создания каталога %s: %v", filepath.Dir(registryLoginAuthFile), err)
+	}
+
+	cmd := exec.Command("skopeo", "login", "--authfile", registryLoginAuthFile,
+		"--username", username, "--password-stdin", registry)
+	cmd.Stdin = strings.NewReader(password)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ошибка входа в %s: %s", registry, strings.TrimSpace(string(output)))
+	}
+
+	registryAuthFile = registryLoginAuthFile
+	return nil
+}
+
+// useAuthFile проверяет существующий auth.json и использует его для всех обращений к реестрам
+func useAuthFile(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("файл учётных данных %s не найден", path)
+	}
+	registryAuthFile = path
+	return nil
+}
+
+// registriesConf формирует drop-in registries.conf с зеркалами и небезопасными реестрами
+func (c RegistryConfig) registriesConf() string {
+	locations := make(map[string]bool)
+	for registry := range c.Mirrors {
+		locations[registry] = true
+	}
+	for _, registry := range c.Insecure {
+		locations[registry] = true
+	}
+
+	var sorted []string
+	for registry := range locations {
+		sorted = append(sorted, registry)
+	}
+	sort.Strings(sorted)
+
+	content := "# Auto generate from atomic-actions installer\n"
+	for _, registry := range sorted {
+		content += fmt.Sprintf("\n[[registry]]\nlocation = %q\n", registry)
+		if c.isInsecure(registry) {
+			content += "insecure = true\n"
+		}
+		if mirror, ok := c.Mirrors[registry]; ok {
+			content += fmt.Sprintf("\n[[registry.mirror]]\nlocation = %q\n", mirror)
+			if c.isInsecure(mirror) {
+				content += "insecure = true\n"
+			}
+		}
+	}
+	return content
+}
+
+// isInsecure сообщает, разрешено ли обращение к реестру без TLS
+func (c RegistryConfig) isInsecure(registry string) bool {
+	for _, insecure := range c.Insecure {
+		if insecure == registry {
+			return true
+		}
+	}
+	return false
+}
+
+// runtimeRegistriesConf дописывает зеркала к копии основного registries.conf хоста, чтобы сохранить поиск
+// по коротким именам, псевдонимы и заблокированные реестры. Ключи верхнего уровня в файле хоста идут
+// до таблиц, поэтому таблицы [[registry]] можно добавить в конец.
+func (c RegistryConfig) runtimeRegistriesConf(hostConf string) string {
+	if hostConf != "" && !strings.HasSuffix(hostConf, "\n") {
+		hostConf += "\n"
+	}
+	if hostConf != "" {
+		hostConf += "\n"
+	}
+	return hostConf + c.registriesConf()
+}
+
+// hostDefinedRegistries возвращает реестры из c.Mirrors, уже описанные в registries.conf хоста:
+// из двух записей с одним адресом действует первая, и зеркало установщика для них не применится
+func (c RegistryConfig) hostDefinedRegistries(hostConf string) []string {
+	var defined []string
+	for registry := range c.Mirrors {
+		pattern := regexp.MustCompile(`(?m)^\s*(location|prefix)\s*=\s*"` + regexp.QuoteMeta(registry) + `"`)
+		if pattern.MatchString(hostConf) {
+			defined = append(defined, registry)
+		}
+	}
+	sort.Strings(defined)
+	return defined
+}
+
+// useRegistriesConf записывает копию registries.conf хоста с зеркалами в registriesRuntimeConf и передаёт её
+// дочерним skopeo и podman через CONTAINERS_REGISTRIES_CONF, /etc/containers работающей системы не изменяется
+func (c RegistryConfig) useRegistriesConf() error {
+	if len(c.Mirrors) == 0 && len(c.Insecure) == 0 {
+		return nil
+	}
+
+	hostConf, err := os.ReadFile(hostRegistriesConf)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("ошибка чтения %s: %v", hostRegistriesConf, err)
+	}
+	for _, registry := range c.hostDefinedRegistries(string(hostConf)) {
+		log.Printf("Предупреждение: реестр %s уже описан в %s, зеркало для него может не примениться\n", registry, hostRegistriesConf)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(registriesRuntimeConf), 0700); err != nil {
+		return fmt.Errorf("ошибка создания каталога %s: %v", filepath.Dir(registriesRuntimeConf), err)
+	}
+
+	if err := os.WriteFile(registriesRuntimeConf, []byte(c.runtimeRegistriesConf(string(hostConf))), 0644); err != nil {
+		return fmt.Errorf("ошибка записи %s: %v", registriesRuntimeConf, err)
+	}
+
+	if err := os.Setenv("CONTAINERS_REGISTRIES_CONF", registriesRuntimeConf); err != nil {
+		return fmt.Errorf("ошибка установки CONTAINERS_REGISTRIES_CONF: %v", err)
+	}
+	return nil
+}
+
+// writeRegistriesConf записывает зеркала в установленную систему с корнем rootPath. Это drop-in файл:
+// registries.conf образа остаётся как есть, а записи drop-in заменяют только реестры с тем же адресом
+func (c RegistryConfig) writeRegistriesConf(rootPath string) error {
+	if len(c.Mirrors) == 0 && len(c.Insecure) == 0 {
+		return nil
+	}
+
+	confPath := filepath.Join(rootPath, registriesDropInPath)
+	if err := os.MkdirAll(filepath.Dir(confPath), 0755); err != nil {
+		return fmt.Errorf("ошибка создания каталога %s: %v", filepath.Dir(confPath), err)
+	}
+
+	if err := os.WriteFile(confPath, []byte(c.registriesConf()), 0644); err != nil {
+		return fmt.Errorf("ошибка записи %s: %v", confPath, err)
+	}
+
+	log.Printf("Файл %s успешно создан.\n", confPath)
+	return nil
+}
+
+// configureTargetRegistries переносит учётные данные и зеркала в установленную систему для обновлений bootc
+func configureTargetRegistries(registry RegistryConfig, ostreeDeployPath string) error {
+	if err := registry.writeRegistriesConf(ostreeDeployPath); err != nil {
+		return err
+	}
+
+	if registryAuthFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(registryAuthFile)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения %s: %v", registryAuthFile, err)
+	}
+
+	authPath := filepath.Join(ostreeDeployPath, targetAuthFilePath)
+	if err := os.MkdirAll(filepath.Dir(authPath), 0755); err != nil {
+		return fmt.Errorf("ошибка создания каталога %s: %v", filepath.Dir(authPath), err)
+	}
+
+	if err := os.WriteFile(authPath, data, 0600); err != nil {
+		return fmt.Errorf("ошибка записи %s: %v", authPath, err)
+	}
+
+	log.Printf("Учётные данные реестра сохранены в %s.\n", authPath)
+	return nil
+}
+
+// parseMirror разбирает значение --registry-mirror вида реестр=зеркало
+func parseMirror(value string) (string, string, error) {
+	registry, mirror, ok := strings.Cut(value, "=")
+	if !ok || registry == "" || mirror == "" {
+		return "", "", fmt.Errorf("ожидается реестр=зеркало, получено %q", value)
+	}
+	return registry, mirror, nil
+}
@@ -0,0 +1,171 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRegistriesConf(t *testing.T) {
+	tests := []struct {
+		name   string
+		config RegistryConfig
+		want   string
+	}{
+		{
+			name:   "без зеркал",
+			config: RegistryConfig{},
+			want:   "# Auto generate from atomic-actions installer\n",
+		},
+		{
+			name: "зеркала и небезопасные реестры по алфавиту",
+			config: RegistryConfig{
+				Mirrors:  map[string]string{"ghcr.io": "mirror.local:5000", "docker.io": "mirror.local:5001/docker"},
+				Insecure: []string{"mirror.local:5000", "registry.local"},
+			},
+			want: `# Auto generate from atomic-actions installer
+
+[[registry]]
+location = "docker.io"
+
+[[registry.mirror]]
+location = "mirror.local:5001/docker"
+
+[[registry]]
+location = "ghcr.io"
+
+[[registry.mirror]]
+location = "mirror.local:5000"
+insecure = true
+
+[[registry]]
+location = "mirror.local:5000"
+insecure = true
+
+[[registry]]
+location = "registry.local"
+insecure = true
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.registriesConf(); got != tt.want {
+				t.Errorf("registriesConf() =\n%s\nожидалось\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMirror(t *testing.T) {
+	tests := []struct {
+		value        string
+		wantRegistry string
+		wantMirror   string
+		wantErr      bool
+	}{
+		{value: "ghcr.io=mirror.local:5000", wantRegistry: "ghcr.io", wantMirror: "mirror.local:5000"},
+		{value: "docker.io=mirror.local/docker=1", wantRegistry: "docker.io", wantMirror: "mirror.local/docker=1"},
+		{value: "ghcr.io", wantErr: true},
+		{value: "=mirror.local", wantErr: true},
+		{value: "ghcr.io=", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			registry, mirror, err := parseMirror(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ошибка = %v, ожидалась ошибка: %t", err, tt.wantErr)
+			}
+			if registry != tt.wantRegistry || mirror != tt.wantMirror {
+				t.Errorf("parseMirror(%q) = %q, %q, ожидалось %q, %q", tt.value, registry, mirror, tt.wantRegistry, tt.wantMirror)
+			}
+		})
+	}
+}
+
+func TestRuntimeRegistriesConf(t *testing.T) {
+	config := RegistryConfig{Mirrors: map[string]string{"ghcr.io": "mirror.local:5000"}}
+	mirrors := config.registriesConf()
+
+	tests := []struct {
+		name     string
+		hostConf string
+		want     string
+	}{
+		{name: "нет файла хоста", hostConf: "", want: mirrors},
+		{
+			name:     "настройки хоста сохраняются перед зеркалами",
+			hostConf: "unqualified-search-registries = [\"registry.altlinux.org\", \"docker.io\"]\n",
+			want:     "unqualified-search-registries = [\"registry.altlinux.org\", \"docker.io\"]\n\n" + mirrors,
+		},
+		{
+			name:     "файл хоста без перевода строки в конце",
+			hostConf: "[aliases]\n\"alpine\" = \"docker.io/library/alpine\"",
+			want:     "[aliases]\n\"alpine\" = \"docker.io/library/alpine\"\n\n" + mirrors,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := config.runtimeRegistriesConf(tt.hostConf); got != tt.want {
+				t.Errorf("runtimeRegistriesConf() =\n%s\nожидалось\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostDefinedRegistries(t *testing.T) {
+	config := RegistryConfig{Mirrors: map[string]string{
+		"docker.io": "mirror.local/docker",
+		"ghcr.io":   "mirror.local/ghcr",
+		"quay.io":   "mirror.local/quay",
+	}}
+	hostConf := `unqualified-search-registries = ["docker.io", "quay.io"]
+
+[[registry]]
+prefix = "ghcr.io"
+location = "ghcr.example.org"
+
+[[registry]]
+  location = "docker.io"
+  blocked = true
+`
+
+	want := []string{"docker.io", "ghcr.io"}
+	if got := config.hostDefinedRegistries(hostConf); !reflect.DeepEqual(got, want) {
+		t.Errorf("hostDefinedRegistries() = %v, ожидалось %v", got, want)
+	}
+}
+
+// В установленную систему пишется только drop-in файл, registries.conf образа не меняется
+func TestWriteRegistriesConf(t *testing.T) {
+	rootPath := t.TempDir()
+	mainConf := filepath.Join(rootPath, "etc/containers/registries.conf")
+	hostConf := "unqualified-search-registries = [\"registry.altlinux.org\"]\n"
+	if err := os.MkdirAll(filepath.Dir(mainConf), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(mainConf, []byte(hostConf), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := RegistryConfig{Mirrors: map[string]string{"ghcr.io": "mirror.local:5000"}}
+	if err := config.writeRegistriesConf(rootPath); err != nil {
+		t.Fatalf("неожиданная ошибка: %v", err)
+	}
+
+	if data, err := os.ReadFile(mainConf); err != nil || string(data) != hostConf {
+		t.Errorf("registries.conf изменён: %q, %v", data, err)
+	}
+	data, err := os.ReadFile(filepath.Join(rootPath, registriesDropInPath))
+	if err != nil {
+		t.Fatalf("drop-in не записан: %v", err)
+	}
+	if string(data) != config.registriesConf() {
+		t.Errorf("drop-in =\n%s\nожидалось\n%s", data, config.registriesConf())
+	}
+}
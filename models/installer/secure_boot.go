@@ -0,0 +1,120 @@
+package installer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// efiGlobalVariableGUID пространство имён стандартных переменных UEFI (SecureBoot, SetupMode)
+const efiGlobalVariableGUID = "8be4df61-93ca-11d2-aa0d-00e098032b8c"
+
+// Пути ключа MOK в развёртывании, их же использует DKMS для подписи модулей
+const (
+	mokPrivateKeyPath  = "/etc/pki/mok/MOK.priv"
+	mokCertificatePath = "/etc/pki/mok/MOK.der"
+	mokDkmsConfigPath  = "/etc/dkms/framework.conf.d/mok.conf"
+)
+
+// SecureBootState состояние Secure Boot, прочитанное из переменных EFI
+type SecureBootState struct {
+	Supported bool // Переменная SecureBoot доступна (загрузка через UEFI)
+	Enabled   bool // Secure Boot включён
+	SetupMode bool // Прошивка в режиме настройки, ключи платформы не установлены
+}
+
+// readSecureBootState читает переменные SecureBoot и SetupMode из efivarfs
+func readSecureBootState() SecureBootState {
+	enabled, supported := readEfiBoolVariable("SecureBoot")
+	setupMode, _ := readEfiBoolVariable("SetupMode")
+	return SecureBootState{Supported: supported, Enabled: enabled, SetupMode: setupMode}
+}
+
+// readEfiBoolVariable читает однобайтовую переменную EFI, первые 4 байта файла efivarfs — атрибуты
+func readEfiBoolVariable(name string) (value bool, exists bool) {
+	data, err := os.ReadFile(fmt.Sprintf("/sys/firmware/efi/efivars/%s-%s", name, efiGlobalVariableGUID))
+	if err != nil || len(data) < 5 {
+		return false, false
+	}
+	return data[4] == 1, true
+}
+
+// Describe возвращает состояние Secure Boot для шага выбора загрузки
+func (s SecureBootState) Describe() string {
+	switch {
+	case !s.Supported:
+		return "Secure Boot: недоступен"
+	case s.SetupMode:
+		return "Secure Boot: режим настройки (ключи не установлены)"
+	case s.Enabled:
+		return "Secure Boot: включён"
+	default:
+		return "Secure Boot: выключен"
+	}
+}
+
+// imageHasUnsignedModules сообщает, содержит ли образ модули ядра без подписи дистрибутива.
+// Для образов не из каталога (entry равен nil) используется соглашение об именах: варианты с драйвером NVIDIA имеют тег с суффиксом -nv.
+func imageHasUnsignedModules(entry *CatalogImage, image string) bool {
+	if entry != nil {
+		return entry.UnsignedModules
+	}
+
+	tag := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(tag, ":"); i >= 0 {
+		tag = tag[i+1:]
+	}
+	return strings.HasSuffix(tag, "-nv")
+}
+
+// generateMokKey создаёт ключ MOK в /etc развёртывания и настраивает DKMS на подпись модулей этим ключом
+func generateMokKey(ostreeDeployPath string) error {
+	privateKey := filepath.Join(ostreeDeployPath, mokPrivateKeyPath)
+	certificate := filepath.Join(ostreeDeployPath, mokCertificatePath)
+	if err := os.MkdirAll(filepath.Dir(privateKey), 0700); err != nil {
+		return fmt.Errorf("ошибка создания каталога %s: %v", filepath.Dir(privateKey), err)
+	}
+
+	log.Println("Создание ключа MOK для подписи модулей ядра...")
+	if err := runCommand("openssl", "req", "-new", "-x509", "-newkey", "rsa:2048", "-nodes",
+		"-days", "36500", "-subj", "/CN=Alt Atomic MOK/",
+		"-keyout", privateKey, "-outform", "DER", "-out", certificate); err != nil {
+		return fmt.Errorf("ошибка создания ключа MOK: %v", err)
+	}
+
+	if err := os.Chmod(privateKey, 0600); err != nil {
+		return fmt.Errorf("ошибка изменения прав %s: %v", privateKey, err)
+	}
+
+	dkmsConfig := filepath.Join(ostreeDeployPath, mokDkmsConfigPath)
+	if err := os.MkdirAll(filepath.Dir(dkmsConfig), 0755); err != nil {
+		return fmt.Errorf("ошибка создания каталога %s: %v", filepath.Dir(dkmsConfig), err)
+	}
+
+	content := fmt.Sprintf("# Auto generate from atomic-actions installer\nmok_signing_key=%s\nmok_certificate=%s\n",
+		mokPrivateKeyPath, mokCertificatePath)
+	if err := os.WriteFile(dkmsConfig, []byte(content), 0644); err != nil {
+		return fmt.Errorf("ошибка записи %s: %v", dkmsConfig, err)
+	}
+	return nil
+}
+
+// enrollMokKey ставит сертификат MOK в очередь на регистрацию, подтверждение выполняется в MokManager при перезагрузке
+func enrollMokKey(ostreeDeployPath string, password string) error {
+	certificate := filepath.Join(ostreeDeployPath, mokCertificatePath)
+
+	log.Println("Регистрация ключа MOK через mokutil...")
+	cmd := exec.Command("mokutil", "--import", certificate)
+	cmd.Stdin = strings.NewReader(password + "\n" + password + "\n")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ошибка регистрации ключа MOK: %v", err)
+	}
+
+	log.Println("При следующей загрузке подтвердите ключ в MokManager (Enroll MOK) паролем, заданным для MOK.")
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"runtime"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -37,23 +38,79 @@ type Image struct {
 	footerMessage string
 	loading       bool
 	inputFocused  bool
+
+	signatures        map[string]SignatureStatus // Результаты проверки подписей по имени образа
+	checkingSignature bool                       // Идёт проверка подписи выбранного образа
+
+	details        map[string]*ImageDetails // Сведения об образах по имени
+	detailsErrors  map[string]string        // Ошибки получения сведений по имени образа
+	loadingDetails map[string]bool          // Образы, сведения о которых запрошены
 }
 
-func RunImageStep() string {
-	p := tea.NewProgram(InitialImage())
+// imageDetailsMsg результат фонового запроса сведений об образе
+type imageDetailsMsg struct {
+	image   string
+	details *ImageDetails
+	err     error
+}
 
-	model, err := p.Run()
-	if err != nil {
-		fmt.Printf("Ошибка во время выбора образа: %v\n", err)
-		os.Exit(1)
+// fetchDetailsCmd запрашивает сведения об образе в фоне
+func fetchDetailsCmd(image string) tea.Cmd {
+	return func() tea.Msg {
+		details, err := getImageDetails(image)
+		return imageDetailsMsg{image: image, details: details, err: err}
+	}
+}
+
+// imageSignatureMsg результат фоновой проверки подписи образа
+type imageSignatureMsg struct {
+	image  string
+	status SignatureStatus
+}
+
+// checkSignatureCmd проверяет подпись образа в фоне, чтобы не блокировать интерфейс.
+// Для предпросмотра дайджест запрашивается здесь же, при установке подпись проверяется по закреплённому дайджесту.
+func checkSignatureCmd(image string) tea.Cmd {
+	return func() tea.Msg {
+		digest := ""
+		if !isLocalImage(image) {
+			var err error
+			if digest, err = imageDigest(image); err != nil {
+				return imageSignatureMsg{image: image, status: SignatureStatus{Message: err.Error()}}
+			}
+		}
+		return imageSignatureMsg{image: image, status: checkImageSignature(image, digest)}
+	}
+}
+
+func RunImageStep(registry *RegistryConfig, catalog []CatalogImage, hardware []string) string {
+	for {
+		p := tea.NewProgram(InitialImage(catalog, hardware))
+
+		model, err := p.Run()
+		if err != nil {
+			fmt.Printf("Ошибка во время выбора образа: %v\n", err)
+			os.Exit(1)
+		}
+
+		// После настройки доступа к реестру список образов строится заново уже с учётными данными
+		imageModel := model.(Image)
+		if imageModel.Result != registrySettingsChoice {
+			return imageModel.Result
+		}
+		RunRegistryStep(registry)
 	}
+}
 
-	imageModel := model.(Image)
-	return imageModel.Result
+// isServiceChoice сообщает, что пункт списка — действие, а не образ
+func isServiceChoice(name string) bool {
+	return name == "Выбрать свой образ" || name == registrySettingsChoice
 }
 
-func InitialImage() Image {
-	images, err := getAvailableImages()
+func InitialImage(catalog []CatalogImage, hardware []string) Image {
+	recommended := recommendedCatalogImage(catalog, hardware)
+
+	images, err := getAvailableImages(catalog, recommended)
 	footerMessage := ""
 	if err != nil {
 		log.Printf(err.Error())
@@ -61,57 +118,83 @@ func InitialImage() Image {
 		images = []Choice{}
 	}
 
-	images = append(images, Choice{Name: "Выбрать свой образ"})
+	// Курсор сразу стоит на образе, подходящем для оборудования
+	cursor := 0
+	for i, image := range images {
+		if image.Name == recommended {
+			cursor = i
+		}
+	}
+
+	images = append(images, Choice{Name: "Выбрать свой образ"}, Choice{Name: registrySettingsChoice})
 	return Image{
-		choices:       images,
-		selected:      -1,
-		confirmActive: false,
-		inputActive:   false,
-		menuCursor:    0,
-		textCursor:    0,
-		inputText:     "",
-		footerMessage: footerMessage,
+		choices:        images,
+		cursor:         cursor,
+		selected:       -1,
+		confirmActive:  false,
+		inputActive:    false,
+		menuCursor:     0,
+		textCursor:     0,
+		inputText:      "",
+		footerMessage:  footerMessage,
+		signatures:     make(map[string]SignatureStatus),
+		details:        make(map[string]*ImageDetails),
+		detailsErrors:  make(map[string]string),
+		loadingDetails: make(map[string]bool),
 	}
 }
 
-func getAvailableImages() ([]Choice, error) {
-	out, err := exec.Command("sudo", "podman", "images", "--format", "json").Output()
+func getAvailableImages(catalog []CatalogImage, recommended string) ([]Choice, error) {
+	out, err := exec.Command("podman", "images", "--format", "json").Output()
 	if err != nil {
-		return addDefaultImage(nil), nil
+		return addCatalogImages(scanOfflineImages(), catalog, recommended), nil
 	}
 
 	var imagesData []ImagePodman
 	if err := json.Unmarshal(out, &imagesData); err != nil {
 		log.Printf("Ошибка парсинга JSON: %v", err)
-		return addDefaultImage(nil), nil
+		return addCatalogImages(scanOfflineImages(), catalog, recommended), nil
 	}
 
 	var images []Choice
 	for _, image := range imagesData {
 		for _, name := range image.Names {
-			images = append(images, Choice{Name: name, Description: ""})
+			// Образ уже есть на хосте и загружается из его хранилища, а не повторно из реестра
+			images = append(images, Choice{Name: "containers-storage:" + name, Description: "Образ из хранилища podman"})
 		}
 	}
 
-	return addDefaultImage(images), nil
+	images = append(images, scanOfflineImages()...)
+	return addCatalogImages(images, catalog, recommended), nil
 }
 
-func addDefaultImage(images []Choice) []Choice {
-	images = append(images,
-		Choice{
-			Name:        "ghcr.io/alt-gnome/alt-atomic:latest",
-			Description: "Базовый образ",
-		},
-		Choice{
-			Name:        "ghcr.io/alt-gnome/alt-atomic:latest-nv",
-			Description: "Для NVIDIA-видеокарт, проприетарный драйвер",
-		},
-	)
+// addCatalogImages добавляет образы из каталога, для уже найденных только подставляет описание
+func addCatalogImages(images []Choice, catalog []CatalogImage, recommended string) []Choice {
+	for _, image := range catalog {
+		description := image.Description
+		if description == "" {
+			description = image.Name
+		}
+		if image.Reference == recommended {
+			description += " (рекомендуется для этого компьютера)"
+		}
+
+		exists := false
+		for i := range images {
+			if images[i].Name == image.Reference {
+				images[i].Description = description
+				exists = true
+			}
+		}
+		if !exists {
+			images = append(images, Choice{Name: image.Reference, Description: description})
+		}
+	}
 	return images
 }
 
 func validateImage(image string) (string, error) {
-	cmd := exec.Command("skopeo", "inspect", "docker://"+image)
+	cmd := skopeoCommand("inspect", skopeoReference(image))
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
@@ -126,14 +209,45 @@ func validateImage(image string) (string, error) {
 	return string(output), nil
 }
 
+// renderSignatureIndicator отображает состояние подписи цветом: проверена, найдена, отсутствует
+func renderSignatureIndicator(status SignatureStatus) string {
+	switch {
+	case status.Trusted():
+		return theme.SuccessStyle.Render("[" + status.Describe() + "]")
+	case status.Signed:
+		return theme.WarningsStyle.Render("[" + status.Describe() + "]")
+	default:
+		return theme.ErrorStyle.Render("[" + status.Describe() + "]")
+	}
+}
+
 func (m Image) Init() tea.Cmd {
-	return nil
+	return m.requestDetails()
+}
+
+// requestDetails запрашивает сведения об образе под курсором, если они ещё не загружались
+func (m Image) requestDetails() tea.Cmd {
+	name := m.choices[m.cursor].Name
+	if isServiceChoice(name) || m.loadingDetails[name] {
+		return nil
+	}
+	m.loadingDetails[name] = true
+	return fetchDetailsCmd(name)
 }
 
 func (m Image) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.footerMessage = ""
 
 	switch msg := msg.(type) {
+	case imageSignatureMsg:
+		m.checkingSignature = false
+		m.signatures[msg.image] = msg.status
+	case imageDetailsMsg:
+		if msg.err != nil {
+			m.detailsErrors[msg.image] = msg.err.Error()
+		} else {
+			m.details[msg.image] = msg.details
+		}
 	case tea.KeyMsg:
 		if strings.HasPrefix(msg.String(), "error:") {
 			m.loading = false
@@ -142,7 +256,7 @@ func (m Image) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.loading = false
 			image := strings.TrimPrefix(msg.String(), "success:")
 			m.footerMessage = theme.SuccessStyle.Render("Валидное изображение: " + image)
-			m.choices = append(m.choices[:len(m.choices)-1], Choice{Name: image}, Choice{Name: "Выбрать свой образ"})
+			m.choices = append(m.choices[:len(m.choices)-2], Choice{Name: image}, Choice{Name: "Выбрать свой образ"}, Choice{Name: registrySettingsChoice})
 			m.inputActive = false
 			m.inputText = ""
 			m.textCursor = 0
@@ -254,23 +368,57 @@ func (m Image) updateChoices(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.cursor > 0 {
 			m.cursor--
 		}
+		return m, m.requestDetails()
 	case "down":
 		if m.cursor < len(m.choices)-1 {
 			m.cursor++
 		}
+		return m, m.requestDetails()
 	case "enter", " ":
-		if m.choices[m.cursor].Name == "Выбрать свой образ" {
+		if m.choices[m.cursor].Name == registrySettingsChoice {
+			m.Result = registrySettingsChoice
+			return m, tea.Quit
+		} else if m.choices[m.cursor].Name == "Выбрать свой образ" {
 			m.inputActive = true
 			m.menuCursor = 0
 		} else {
 			m.selected = m.cursor
 			m.confirmActive = true
 			m.confirmCursor = 0
+
+			if _, checked := m.signatures[m.choices[m.cursor].Name]; !checked {
+				m.checkingSignature = true
+				return m, checkSignatureCmd(m.choices[m.cursor].Name)
+			}
 		}
 	}
 	return m, nil
 }
 
+// renderDetails отображает сведения об образе под курсором
+func (m Image) renderDetails(name string) string {
+	if isServiceChoice(name) {
+		return ""
+	}
+	if errorMessage, ok := m.detailsErrors[name]; ok {
+		return "\n" + theme.ErrorStyle.Render(errorMessage) + "\n"
+	}
+
+	details, ok := m.details[name]
+	if !ok {
+		return "\n" + theme.LoadingStyle.Render("Загрузка сведений об образе...") + "\n"
+	}
+
+	result := "\n"
+	for _, line := range details.Describe() {
+		result += theme.LoadingStyle.Render(line) + "\n"
+	}
+	if details.ArchMismatch() {
+		result += theme.WarningsStyle.Render(fmt.Sprintf("Образ собран для %s, а компьютер — %s", details.Architecture, runtime.GOARCH)) + "\n"
+	}
+	return result
+}
+
 func (m Image) View() string {
 	header := theme.HeaderStyle.Render("Добро пожаловать в установку Alt Atomic \nВыберите образ:")
 
@@ -289,7 +437,15 @@ func (m Image) View() string {
 		if choice.Description != "" {
 			desc = theme.LoadingStyle.Render(" - " + choice.Description)
 		}
-		body += fmt.Sprintf("%s [%s] %s%s\n", cursor, checked, choice.Name, desc)
+		signature := ""
+		if status, ok := m.signatures[choice.Name]; ok {
+			signature = " " + renderSignatureIndicator(status)
+		}
+		body += fmt.Sprintf("%s [%s] %s%s%s\n", cursor, checked, choice.Name, desc, signature)
+	}
+
+	if !m.inputActive && m.selected == -1 {
+		body += m.renderDetails(m.choices[m.cursor].Name)
 	}
 
 	if m.inputActive {
@@ -318,6 +474,14 @@ func (m Image) View() string {
 	if m.Result == "" && m.selected != -1 {
 		selectedName := m.choices[m.selected].Name
 		body += "\nВы уверены, что хотите выбрать изображение " + theme.SelectedStyle.Render(selectedName) + "?\n"
+		if details, ok := m.details[selectedName]; ok && details.ArchMismatch() {
+			body += theme.WarningsStyle.Render(fmt.Sprintf("Архитектура образа %s не совпадает с архитектурой компьютера %s", details.Architecture, runtime.GOARCH)) + "\n"
+		}
+		if m.checkingSignature {
+			body += theme.LoadingStyle.Render("Проверка подписи...") + "\n"
+		} else if status, ok := m.signatures[selectedName]; ok {
+			body += "Подпись: " + renderSignatureIndicator(status) + "\n"
+		}
 		confirmOptions := []string{"Да", "Отмена"}
 		for i, option := range confirmOptions {
 			cursor := " "
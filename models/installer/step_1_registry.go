@@ -0,0 +1,250 @@
+package installer
+
+import (
+	"atomic-actions/models/installer/theme"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// registrySettingsChoice пункт шага выбора образа, открывающий настройку доступа к реестру
+const registrySettingsChoice = "Настроить доступ к реестру"
+
+// Поля формы доступа к реестру в порядке перехода по Tab
+const (
+	registryFieldRegistry = iota
+	registryFieldUsername
+	registryFieldToken
+	registryFieldAuthFile
+	registryFieldMirror
+	registryFieldInsecure
+	registryFieldApply
+	registryFieldBack
+	registryFieldsCount
+)
+
+type RegistryAccess struct {
+	Registry string // Реестр, например ghcr.io
+	Username string // Имя пользователя
+	Token    string // Пароль или токен доступа
+	AuthFile string // Существующий auth.json вместо входа
+	Mirror   string // Зеркало для реестра
+	Insecure bool   // Зеркало без TLS
+
+	config        *RegistryConfig // Изменяемая конфигурация доступа
+	focusedField  int             // Поле в фокусе
+	cursor        int             // Позиция курсора в поле
+	loading       bool            // Выполняется вход в реестр
+	errorMessage  string          // Ошибка входа или проверки
+	footerMessage string          // Подсказка
+}
+
+// registryLoginMsg результат фонового входа в реестр
+type registryLoginMsg struct {
+	err error
+}
+
+// RunRegistryStep настраивает учётные данные и зеркала реестра, изменения сохраняются в config
+func RunRegistryStep(config *RegistryConfig) {
+	p := tea.NewProgram(InitialRegistryAccess(config))
+	if _, err := p.Run(); err != nil {
+		fmt.Printf("Ошибка во время настройки доступа к реестру: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func InitialRegistryAccess(config *RegistryConfig) RegistryAccess {
+	return RegistryAccess{
+		AuthFile:      config.AuthFile,
+		config:        config,
+		footerMessage: "Укажите логин и токен или готовый auth.json. Зеркало применяется к указанному реестру.",
+	}
+}
+
+func (m RegistryAccess) Init() tea.Cmd {
+	return nil
+}
+
+// fieldValue возвращает указатель на значение текстового поля в фокусе
+func (m *RegistryAccess) fieldValue() *string {
+	switch m.focusedField {
+	case registryFieldRegistry:
+		return &m.Registry
+	case registryFieldUsername:
+		return &m.Username
+	case registryFieldToken:
+		return &m.Token
+	case registryFieldAuthFile:
+		return &m.AuthFile
+	case registryFieldMirror:
+		return &m.Mirror
+	}
+	return nil
+}
+
+func (m RegistryAccess) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case registryLoginMsg:
+		m.loading = false
+		if msg.err != nil {
+			m.errorMessage = msg.err.Error()
+			return m, nil
+		}
+		return m.applyMirror()
+	case tea.KeyMsg:
+		if m.loading {
+			return m, nil
+		}
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			return m, tea.Quit
+		case "tab", "down":
+			m.focusedField = (m.focusedField + 1) % registryFieldsCount
+			m.cursor = 0
+		case "shift+tab", "up":
+			m.focusedField = (m.focusedField + registryFieldsCount - 1) % registryFieldsCount
+			m.cursor = 0
+		case "left":
+			if m.fieldValue() != nil && m.cursor > 0 {
+				m.cursor--
+			}
+		case "right":
+			if value := m.fieldValue(); value != nil && m.cursor < len(*value) {
+				m.cursor++
+			}
+		case "enter", " ":
+			// В текстовых полях пробел вводится как символ, а Enter переводит к следующему полю
+			if value := m.fieldValue(); value != nil {
+				if msg.String() == "enter" {
+					m.focusedField++
+					m.cursor = 0
+				} else {
+					*value, m.cursor = handleTextInputWithCursor(*value, msg, m.cursor)
+				}
+				return m, nil
+			}
+
+			switch m.focusedField {
+			case registryFieldInsecure:
+				m.Insecure = !m.Insecure
+			case registryFieldApply:
+				return m.apply()
+			case registryFieldBack:
+				return m, tea.Quit
+			}
+		default:
+			if value := m.fieldValue(); value != nil {
+				*value, m.cursor = handleTextInputWithCursor(*value, msg, m.cursor)
+				m.errorMessage = ""
+			}
+		}
+	}
+	return m, nil
+}
+
+// apply использует auth.json или выполняет вход в реестр, затем сохраняет зеркало
+func (m RegistryAccess) apply() (tea.Model, tea.Cmd) {
+	m.errorMessage = ""
+
+	if m.AuthFile != "" {
+		if err := useAuthFile(m.AuthFile); err != nil {
+			m.errorMessage = err.Error()
+			return m, nil
+		}
+		m.config.AuthFile = m.AuthFile
+		return m.applyMirror()
+	}
+
+	if m.Username != "" || m.Token != "" {
+		if m.Registry == "" || m.Username == "" || m.Token == "" {
+			m.errorMessage = "Для входа нужны реестр, имя пользователя и токен."
+			return m, nil
+		}
+		m.loading = true
+		registry, username, token := m.Registry, m.Username, m.Token
+		return m, func() tea.Msg {
+			return registryLoginMsg{err: registryLogin(registry, username, token)}
+		}
+	}
+
+	return m.applyMirror()
+}
+
+// applyMirror сохраняет зеркало реестра и записывает drop-in для skopeo и podman установщика
+func (m RegistryAccess) applyMirror() (tea.Model, tea.Cmd) {
+	if registryAuthFile != "" {
+		m.config.AuthFile = registryAuthFile
+	}
+
+	if m.Mirror != "" {
+		if m.Registry == "" {
+			m.errorMessage = "Укажите реестр, для которого настраивается зеркало."
+			return m, nil
+		}
+		if m.config.Mirrors == nil {
+			m.config.Mirrors = make(map[string]string)
+		}
+		m.config.Mirrors[m.Registry] = m.Mirror
+		if m.Insecure && !m.config.isInsecure(m.Mirror) {
+			m.config.Insecure = append(m.config.Insecure, m.Mirror)
+		}
+	}
+
+	if err := m.config.useRegistriesConf(); err != nil {
+		m.errorMessage = err.Error()
+		return m, nil
+	}
+	return m, tea.Quit
+}
+
+func (m RegistryAccess) View() string {
+	header := theme.HeaderStyle.Render("Доступ к реестру образов")
+
+	renderField := func(field int, label string, value string, masked bool) string {
+		if masked {
+			value = strings.Repeat("*", len(value))
+		}
+		if m.focusedField == field {
+			value = value[:m.cursor] + theme.CursorStyle.Render("|") + value[m.cursor:]
+		}
+		return label + ":\n" + theme.InputStyle.Render(value) + "\n"
+	}
+
+	body := renderField(registryFieldRegistry, "Реестр (например, ghcr.io)", m.Registry, false)
+	body += renderField(registryFieldUsername, "Имя пользователя", m.Username, false)
+	body += renderField(registryFieldToken, "Пароль или токен", m.Token, true)
+	body += renderField(registryFieldAuthFile, "Или путь к auth.json", m.AuthFile, false)
+	body += renderField(registryFieldMirror, "Зеркало реестра (например, mirror.local:5000)", m.Mirror, false)
+
+	checked := " "
+	if m.Insecure {
+		checked = theme.SelectedStyle.Render("x")
+	}
+	buttons := []struct {
+		field int
+		label string
+	}{
+		{registryFieldInsecure, fmt.Sprintf("[%s] Зеркало без TLS", checked)},
+		{registryFieldApply, "Применить"},
+		{registryFieldBack, "Назад"},
+	}
+	body += "\n"
+	for _, button := range buttons {
+		cursor := " "
+		if m.focusedField == button.field {
+			cursor = theme.CursorStyle.Render(">")
+		}
+		body += fmt.Sprintf("%s %s\n", cursor, button.label)
+	}
+
+	footer := "\n" + m.footerMessage + "\n"
+	if m.loading {
+		footer += theme.LoadingStyle.Render("Вход в реестр... Пожалуйста, подождите.") + "\n"
+	}
+	if m.errorMessage != "" {
+		footer += theme.ErrorStyle.Render(m.errorMessage) + "\n"
+	}
+	return header + "\n\n" + body + theme.FooterStyle.Render(footer)
+}
@@ -0,0 +1,222 @@
+package installer
+
+import (
+	"atomic-actions/models/installer/theme"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"os"
+	"strings"
+)
+
+// btrfsCompressions варианты сжатия, переключаемые в настройках btrfs
+var btrfsCompressions = []struct {
+	Algorithm string
+	Level     int
+}{
+	{"zstd", 1},
+	{"zstd", 3},
+	{"zstd", 9},
+	{"lzo", 0},
+	{"zlib", 3},
+	{"none", 0},
+}
+
+// btrfsOptionalSubvolumes дополнительные подтома, которые можно включить в разметку
+var btrfsOptionalSubvolumes = []struct {
+	Subvolume   BtrfsSubvolume
+	Description string
+}{
+	{BtrfsSubvolume{Name: "@snapshots"}, "хранилище снимков, не монтируется"},
+	{BtrfsSubvolume{Name: "@var-log", MountPoint: "/var/log"}, "журналы в /var/log"},
+	{BtrfsSubvolume{Name: "@containers", MountPoint: "/var/lib/containers", NoCOW: true}, "контейнеры в /var/lib/containers, nodatacow"},
+}
+
+type BtrfsOptions struct {
+	Result      *BtrfsProfile
+	profile     BtrfsProfile
+	subvolumes  []bool // Включённые дополнительные подтома
+	compression int    // Индекс в btrfsCompressions
+	cursor      int    // Текущая позиция курсора
+	errorText   string // Ошибка проверки профиля
+}
+
+// RunBtrfsOptionsStep настраивает подтома и опции монтирования btrfs, nil — настройка отменена
+func RunBtrfsOptionsStep(profile BtrfsProfile) *BtrfsProfile {
+	p := tea.NewProgram(InitialBtrfsOptions(profile))
+
+	model, err := p.Run()
+	if err != nil {
+		fmt.Printf("Ошибка во время настройки btrfs: %v\n", err)
+		os.Exit(1)
+	}
+
+	btrfsModel := model.(BtrfsOptions)
+	return btrfsModel.Result
+}
+
+func InitialBtrfsOptions(profile BtrfsProfile) BtrfsOptions {
+	m := BtrfsOptions{
+		profile:    profile,
+		subvolumes: make([]bool, len(btrfsOptionalSubvolumes)),
+	}
+
+	for i, optional := range btrfsOptionalSubvolumes {
+		for _, subVol := range profile.Subvolumes {
+			if subVol.Name == optional.Subvolume.Name {
+				m.subvolumes[i] = true
+			}
+		}
+	}
+
+	for i, compression := range btrfsCompressions {
+		if compression.Algorithm == profile.Compression && compression.Level == profile.CompressionLevel {
+			m.compression = i
+		}
+	}
+
+	return m
+}
+
+// itemsCount количество пунктов: подтома, сжатие, noatime, ssd, discard, снимки, snapper и кнопка продолжения
+func (m BtrfsOptions) itemsCount() int {
+	return len(btrfsOptionalSubvolumes) + 7
+}
+
+// buildProfile собирает профиль из базовой разметки и выбранных опций
+func (m BtrfsOptions) buildProfile() BtrfsProfile {
+	profile := m.profile
+	profile.Subvolumes = nil
+
+	optional := make(map[string]bool)
+	for _, item := range btrfsOptionalSubvolumes {
+		optional[item.Subvolume.Name] = true
+	}
+	for _, subVol := range m.profile.Subvolumes {
+		if !optional[subVol.Name] {
+			profile.Subvolumes = append(profile.Subvolumes, subVol)
+		}
+	}
+	for i, item := range btrfsOptionalSubvolumes {
+		if m.subvolumes[i] {
+			profile.Subvolumes = append(profile.Subvolumes, item.Subvolume)
+		}
+	}
+
+	profile.Compression = btrfsCompressions[m.compression].Algorithm
+	profile.CompressionLevel = btrfsCompressions[m.compression].Level
+	return profile
+}
+
+func (m BtrfsOptions) Init() tea.Cmd {
+	return nil
+}
+
+func (m BtrfsOptions) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		subvolumesCount := len(btrfsOptionalSubvolumes)
+
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < m.itemsCount()-1 {
+				m.cursor++
+			}
+		case "left", "h":
+			if m.cursor == subvolumesCount {
+				m.compression = (m.compression + len(btrfsCompressions) - 1) % len(btrfsCompressions)
+			}
+		case "right", "l":
+			if m.cursor == subvolumesCount {
+				m.compression = (m.compression + 1) % len(btrfsCompressions)
+			}
+		case "enter", " ":
+			switch {
+			case m.cursor < subvolumesCount:
+				m.subvolumes[m.cursor] = !m.subvolumes[m.cursor]
+			case m.cursor == subvolumesCount:
+				m.compression = (m.compression + 1) % len(btrfsCompressions)
+			case m.cursor == subvolumesCount+1:
+				m.profile.NoAtime = !m.profile.NoAtime
+			case m.cursor == subvolumesCount+2:
+				m.profile.SSD = !m.profile.SSD
+			case m.cursor == subvolumesCount+3:
+				m.profile.Discard = !m.profile.Discard
+			case m.cursor == subvolumesCount+4:
+				m.profile.FactorySnapshot = !m.profile.FactorySnapshot
+			case m.cursor == subvolumesCount+5:
+				m.profile.Snapper = !m.profile.Snapper
+			default:
+				profile := m.buildProfile()
+				if err := profile.Validate(); err != nil {
+					m.errorText = err.Error()
+					return m, nil
+				}
+				m.Result = &profile
+				return m, tea.Quit
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m BtrfsOptions) View() string {
+	header := theme.HeaderStyle.Render("Настройка btrfs:")
+
+	checkbox := func(checked bool) string {
+		if checked {
+			return theme.SelectedStyle.Render("x")
+		}
+		return " "
+	}
+
+	var lines []string
+	for i, item := range btrfsOptionalSubvolumes {
+		lines = append(lines, fmt.Sprintf("[%s] Подтом %s (%s)", checkbox(m.subvolumes[i]), item.Subvolume.Name, item.Description))
+	}
+
+	compression := btrfsCompressions[m.compression]
+	compressionText := compression.Algorithm
+	if compression.Level > 0 {
+		compressionText = fmt.Sprintf("%s:%d", compression.Algorithm, compression.Level)
+	}
+	lines = append(lines,
+		fmt.Sprintf("Сжатие: < %s >", theme.SelectedStyle.Render(compressionText)),
+		fmt.Sprintf("[%s] noatime (не обновлять время доступа)", checkbox(m.profile.NoAtime)),
+		fmt.Sprintf("[%s] ssd (оптимизации для SSD)", checkbox(m.profile.SSD)),
+		fmt.Sprintf("[%s] discard=async (TRIM в фоне)", checkbox(m.profile.Discard)),
+		fmt.Sprintf("[%s] Заводской снимок изменяемых подтомов после установки", checkbox(m.profile.FactorySnapshot)),
+		fmt.Sprintf("[%s] Настроить snapper для изменяемых подтомов", checkbox(m.profile.Snapper)),
+		"Продолжить",
+	)
+
+	var body string
+	for i, line := range lines {
+		cursor := " "
+		if m.cursor == i {
+			cursor = theme.CursorStyle.Render(">")
+		}
+		body += fmt.Sprintf("%s %s\n", cursor, line)
+	}
+
+	var names []string
+	for _, subVol := range m.buildProfile().Subvolumes {
+		if subVol.MountPoint != "" {
+			names = append(names, fmt.Sprintf("%s → %s", subVol.Name, subVol.MountPoint))
+		} else {
+			names = append(names, subVol.Name)
+		}
+	}
+	body += "\nПодтома: " + strings.Join(names, ", ") + "\n"
+
+	footer := "\nПробел — переключить, ←/→ — выбрать сжатие.\n"
+	if m.errorText != "" {
+		footer += theme.ErrorStyle.Render(m.errorText) + "\n"
+	}
+	return header + "\n\n" + body + theme.FooterStyle.Render(footer)
+}
@@ -17,8 +17,8 @@ type Filesystem struct {
 	confirmCursor int      // Позиция курсора в меню подтверждения
 }
 
-func RunFilesystemStep() string {
-	p := tea.NewProgram(InitialFilesystem())
+func RunFilesystemStep(recommended string) string {
+	p := tea.NewProgram(InitialFilesystem(recommended))
 
 	model, err := p.Run()
 	if err != nil {
@@ -30,9 +30,19 @@ func RunFilesystemStep() string {
 	return fsModel.Result
 }
 
-func InitialFilesystem() Filesystem {
+func InitialFilesystem(recommended string) Filesystem {
+	var choices []string
+	cursor := 0
+	for i, driver := range filesystemDrivers {
+		choices = append(choices, fmt.Sprintf("%s (%s)", driver.Name(), driver.Description()))
+		if driver.Name() == recommended {
+			cursor = i
+		}
+	}
+
 	return Filesystem{
-		choices:       []string{"btrfs (Будут добавлены subvolume:@, @home, @var)", "ext4 (Установка в корень /)"},
+		choices:       choices,
+		cursor:        cursor,
 		selected:      -1,
 		confirmActive: false,
 		confirmCursor: 0,
@@ -5,29 +5,30 @@ import (
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
 	"os"
+	"regexp"
 	"strings"
 )
 
 type BootMode struct {
 	Result        string
-	choices       []string // Список выбора (UEFI, LEGACY)
-	cursor        int      // Текущая позиция курсора
-	selected      int      // Выбранный элемент (только один)
-	confirmActive bool     // Включено ли меню подтверждения
-	confirmCursor int      // Позиция курсора в меню подтверждения
-	infoMessage   string   // Информация о поддержке UEFI
-	uefiSupported bool     // Состояние поддержки компьютером
+	choices       []string        // Список выбора (UEFI, LEGACY, HYBRID)
+	cursor        int             // Текущая позиция курсора
+	selected      int             // Выбранный элемент (только один)
+	confirmActive bool            // Включено ли меню подтверждения
+	confirmCursor int             // Позиция курсора в меню подтверждения
+	infoMessage   string          // Информация о поддержке UEFI
+	uefiSupported bool            // Состояние поддержки компьютером
+	secureBoot    SecureBootState // Состояние Secure Boot
+	warning       string          // Предупреждение о модулях без подписи при включённом Secure Boot
 }
 
-func RunBootModeStep() string {
-	// Сначала проверяем поддержку UEFI
+func RunBootModeStep(recommended string, warning string) string {
+	// Без поддержки UEFI выбор остаётся только между LEGACY и HYBRID
 	if !checkUEFISupport() {
-		fmt.Println(theme.WarningsStyle.Render("Система не поддерживает UEFI. Автоматически выбран LEGACY."))
-		return "LEGACY"
+		fmt.Println(theme.WarningsStyle.Render("Система не поддерживает UEFI."))
 	}
 
-	// Если поддержка есть, запускаем TUI
-	p := tea.NewProgram(InitialBootMode())
+	p := tea.NewProgram(InitialBootMode(recommended, warning))
 	model, err := p.Run()
 	if err != nil {
 		fmt.Printf("Ошибка во время выбора типа загрузки: %v\n", err)
@@ -39,23 +40,39 @@ func RunBootModeStep() string {
 	return strings.Split(bootModel.Result, " ")[0]
 }
 
-func InitialBootMode() BootMode {
+func InitialBootMode(recommended string, warning string) BootMode {
 	uefiSupported := checkUEFISupport()
 	infoMessage := ""
 
+	choices := []string{
+		"LEGACY (загрузка через BIOS)",
+		"HYBRID (BIOS и UEFI на одном диске, загружается на любой прошивке)",
+	}
 	if uefiSupported {
+		choices = append([]string{"UEFI (рекомендуется для современных систем)"}, choices...)
 		infoMessage = "Ваш компьютер поддерживает UEFI загрузку, это - рекомендуемый выбор."
 	} else {
-		infoMessage = "Ваш компьютер не поддерживает UEFI, рекомендуем выбрать LEGACY."
+		infoMessage = "Ваш компьютер не поддерживает UEFI, рекомендуем выбрать LEGACY или HYBRID для переносимого диска."
+	}
+
+	// Курсор сразу стоит на типе загрузки, рекомендованном по оборудованию
+	cursor := 0
+	for i, choice := range choices {
+		if strings.Split(choice, " ")[0] == recommended {
+			cursor = i
+		}
 	}
 
 	return BootMode{
-		choices:       []string{"UEFI (рекомендуется для современных систем)", "LEGACY (совместимый вариант UEFI|Legacy bios)"},
+		choices:       choices,
+		cursor:        cursor,
 		selected:      -1,
 		confirmActive: false,
 		confirmCursor: 0,
 		infoMessage:   infoMessage,
 		uefiSupported: uefiSupported,
+		secureBoot:    readSecureBootState(),
+		warning:       warning,
 	}
 }
 
@@ -150,5 +167,132 @@ func (m BootMode) View() string {
 		footer += theme.WarningsStyle.Render(m.infoMessage)
 	}
 
+	if m.secureBoot.Supported {
+		footer += "\n" + theme.SuccessInfoStyle.Render(m.secureBoot.Describe())
+	}
+	if m.warning != "" {
+		footer += "\n" + theme.WarningsStyle.Render(m.warning)
+	}
+
 	return header + "\n\n" + body + footer
 }
+
+// kernelArgKeyPattern допустимые имена аргументов ядра: nomodeset, rd.luks.uuid, console
+var kernelArgKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+
+// reservedKernelArgs аргументы, которые формирует bootc и которые нельзя переопределить
+var reservedKernelArgs = []string{"root", "rootflags", "ostree"}
+
+// parseKernelArgs разбивает строку аргументов ядра по пробелам
+func parseKernelArgs(text string) []string {
+	return strings.Fields(text)
+}
+
+// validateKernelArgs проверяет аргументы ядра перед передачей в bootc --karg
+func validateKernelArgs(kargs []string) error {
+	for _, karg := range kargs {
+		if strings.ContainsAny(karg, "\"'`\\$;") {
+			return fmt.Errorf("недопустимые символы в аргументе %q", karg)
+		}
+
+		key := strings.SplitN(karg, "=", 2)[0]
+		if !kernelArgKeyPattern.MatchString(key) {
+			return fmt.Errorf("некорректное имя аргумента %q", karg)
+		}
+
+		for _, reserved := range reservedKernelArgs {
+			if key == reserved {
+				return fmt.Errorf("аргумент %s задаётся установщиком автоматически", reserved)
+			}
+		}
+	}
+	return nil
+}
+
+// kernelArgsPreview показывает строку options BLS-записи, которую сформирует bootc
+func kernelArgsPreview(rootMountOptions string, kargs []string) string {
+	options := []string{"root=UUID=<root>", "rw"}
+	if rootMountOptions != "" {
+		options = append(options, "rootflags="+rootMountOptions)
+	}
+	options = append(options, kargs...)
+	options = append(options, "ostree=/ostree/boot.1/default/<checksum>/0")
+	return "options " + strings.Join(options, " ")
+}
+
+type KernelArgs struct {
+	Result           []string // Аргументы ядра
+	Done             bool     // Ввод подтверждён
+	inputText        string   // Текст поля ввода
+	textCursor       int      // Позиция курсора в поле ввода
+	rootMountOptions string   // Опции монтирования корня для предпросмотра
+	errorMessage     string   // Ошибка проверки аргументов
+}
+
+// RunKernelArgsStep запрашивает дополнительные аргументы ядра, ok=false — ввод отменён
+func RunKernelArgsStep(rootMountOptions string) ([]string, bool) {
+	p := tea.NewProgram(InitialKernelArgs(rootMountOptions))
+	model, err := p.Run()
+	if err != nil {
+		fmt.Printf("Ошибка во время ввода аргументов ядра: %v\n", err)
+		os.Exit(1)
+	}
+
+	kargsModel := model.(KernelArgs)
+	return kargsModel.Result, kargsModel.Done
+}
+
+func InitialKernelArgs(rootMountOptions string) KernelArgs {
+	return KernelArgs{rootMountOptions: rootMountOptions}
+}
+
+func (m KernelArgs) Init() tea.Cmd {
+	return nil
+}
+
+func (m KernelArgs) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			return m, tea.Quit
+		case "left":
+			if m.textCursor > 0 {
+				m.textCursor--
+			}
+		case "right":
+			if m.textCursor < len(m.inputText) {
+				m.textCursor++
+			}
+		case "enter":
+			kargs := parseKernelArgs(m.inputText)
+			if err := validateKernelArgs(kargs); err != nil {
+				m.errorMessage = err.Error()
+				return m, nil
+			}
+			m.Result = kargs
+			m.Done = true
+			return m, tea.Quit
+		default:
+			m.inputText, m.textCursor = handleTextInputWithCursor(m.inputText, msg, m.textCursor)
+			m.errorMessage = ""
+		}
+	}
+	return m, nil
+}
+
+func (m KernelArgs) View() string {
+	header := theme.HeaderStyle.Render("Дополнительные аргументы ядра (необязательно):")
+
+	renderedInput := m.inputText[:m.textCursor] + theme.CursorStyle.Render("|") + m.inputText[m.textCursor:]
+	body := theme.InputStyle.Render(renderedInput) + "\n\n"
+
+	body += "Запись загрузчика:\n"
+	body += theme.LoadingStyle.Render(kernelArgsPreview(m.rootMountOptions, parseKernelArgs(m.inputText))) + "\n"
+
+	footer := "\nНапример: nomodeset console=ttyS0,115200 iommu=pt. Enter — продолжить, Esc — отмена.\n"
+	if m.errorMessage != "" {
+		footer += theme.ErrorStyle.Render(m.errorMessage) + "\n"
+	}
+	return header + "\n\n" + body + theme.FooterStyle.Render(footer)
+}
@@ -0,0 +1,42 @@
+package installer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateKernelArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		kargs   []string
+		wantErr string // Часть текста ошибки, пусто — ошибки нет
+	}{
+		{name: "без аргументов"},
+		{name: "флаг и значения", kargs: []string{"nomodeset", "console=ttyS0,115200", "rd.luks.uuid=abc-123", "iommu=pt"}},
+		{name: "значение со знаком равенства", kargs: []string{"systemd.setenv=A=B"}},
+		{name: "кавычки", kargs: []string{`quiet="1"`}, wantErr: "недопустимые символы"},
+		{name: "подстановка shell", kargs: []string{"a=$(reboot)"}, wantErr: "недопустимые символы"},
+		{name: "точка с запятой", kargs: []string{"a;b"}, wantErr: "недопустимые символы"},
+		{name: "пустое имя", kargs: []string{"=1"}, wantErr: "некорректное имя аргумента"},
+		{name: "недопустимое имя", kargs: []string{"a/b=1"}, wantErr: "некорректное имя аргумента"},
+		{name: "root задаёт установщик", kargs: []string{"root=/dev/sda1"}, wantErr: "задаётся установщиком"},
+		{name: "rootflags задаёт установщик", kargs: []string{"rootflags=subvol=@"}, wantErr: "задаётся установщиком"},
+		{name: "ostree задаёт установщик", kargs: []string{"quiet", "ostree"}, wantErr: "задаётся установщиком"},
+		{name: "похожее на зарезервированное имя", kargs: []string{"rootwait", "root.x=1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateKernelArgs(tt.kargs)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("неожиданная ошибка: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ошибка = %v, ожидалась содержащая %q", err, tt.wantErr)
+			}
+		})
+	}
+}
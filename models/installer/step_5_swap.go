@@ -0,0 +1,174 @@
+package installer
+
+import (
+	"atomic-actions/models/installer/theme"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"os"
+)
+
+type Swap struct {
+	Result        *SwapConfig  // Результат выбора
+	choices       []SwapConfig // Варианты подкачки
+	cursor        int          // Текущая позиция курсора
+	selected      int          // Выбранный элемент (только один)
+	confirmActive bool         // Включено ли меню подтверждения
+	confirmCursor int          // Позиция курсора в меню подтверждения
+	memoryMiB     int          // Объём оперативной памяти
+	recommended   int          // Вариант, рекомендованный по оборудованию
+}
+
+func RunSwapStep(recommended SwapConfig) *SwapConfig {
+	p := tea.NewProgram(InitialSwap(recommended))
+
+	model, err := p.Run()
+	if err != nil {
+		fmt.Printf("Ошибка во время выбора подкачки: %v\n", err)
+		os.Exit(1)
+	}
+
+	swapModel := model.(Swap)
+	return swapModel.Result
+}
+
+func InitialSwap(recommended SwapConfig) Swap {
+	memoryMiB, err := getMemoryMiB()
+	if err != nil {
+		fmt.Println(theme.WarningsStyle.Render(err.Error()))
+		memoryMiB = 4096
+	}
+
+	choices := []SwapConfig{
+		{Mode: "zram"},
+		{Mode: "partition", SizeMiB: recommendedSwapMiB(memoryMiB, false)},
+		{Mode: "partition", SizeMiB: recommendedSwapMiB(memoryMiB, true), Hibernate: true},
+		{Mode: "file", SizeMiB: recommendedSwapMiB(memoryMiB, false)},
+		{Mode: "none"},
+	}
+	// Рекомендованный раздел мог быть урезан до свободного места на диске
+	if recommended.Mode == "partition" && !recommended.Hibernate {
+		choices[1].SizeMiB = recommended.SizeMiB
+	}
+
+	// Курсор сразу стоит на варианте, рекомендованном по оборудованию
+	cursor := 0
+	for i, choice := range choices {
+		if choice.Mode == recommended.Mode && choice.Hibernate == recommended.Hibernate {
+			cursor = i
+		}
+	}
+
+	return Swap{
+		choices:       choices,
+		cursor:        cursor,
+		recommended:   cursor,
+		selected:      -1,
+		confirmActive: false,
+		confirmCursor: 0,
+		memoryMiB:     memoryMiB,
+	}
+}
+
+// describeSwap возвращает описание варианта подкачки для списка выбора
+func describeSwap(swap SwapConfig) string {
+	switch {
+	case swap.Mode == "zram":
+		return "zram (сжатая подкачка в памяти)"
+	case swap.Mode == "partition" && swap.Hibernate:
+		return fmt.Sprintf("Раздел подкачки с гибернацией (%.1f ГБ)", float64(swap.SizeMiB)/1024)
+	case swap.Mode == "partition":
+		return fmt.Sprintf("Раздел подкачки (%.1f ГБ)", float64(swap.SizeMiB)/1024)
+	case swap.Mode == "file":
+		return fmt.Sprintf("Файл подкачки %s (%.1f ГБ)", swapFilePath, float64(swap.SizeMiB)/1024)
+	default:
+		return "Без подкачки"
+	}
+}
+
+func (m Swap) Init() tea.Cmd {
+	return nil
+}
+
+func (m Swap) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.confirmActive {
+			switch msg.String() {
+			case "up", "k":
+				if m.confirmCursor > 0 {
+					m.confirmCursor--
+				}
+			case "down", "j":
+				if m.confirmCursor < 1 {
+					m.confirmCursor++
+				}
+			case "enter", " ":
+				if m.confirmCursor == 0 {
+					m.Result = &m.choices[m.selected]
+					return m, tea.Quit
+				} else {
+					m.selected = -1
+					m.confirmActive = false
+				}
+			case "ctrl+c", "q":
+				return m, tea.Quit
+			}
+		} else {
+			switch msg.String() {
+			case "ctrl+c", "q":
+				return m, tea.Quit
+			case "up", "k":
+				if m.cursor > 0 {
+					m.cursor--
+				}
+			case "down", "j":
+				if m.cursor < len(m.choices)-1 {
+					m.cursor++
+				}
+			case "enter", " ":
+				m.selected = m.cursor
+				m.confirmActive = true
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m Swap) View() string {
+	header := theme.HeaderStyle.Render("Выберите подкачку (swap):")
+
+	var body string
+	for i, choice := range m.choices {
+		cursor := ""
+		if m.cursor == i {
+			cursor = theme.CursorStyle.Render(">")
+		}
+
+		checked := " " // Не выбрано
+		if m.selected == i {
+			checked = theme.SelectedStyle.Render("x")
+		}
+
+		description := describeSwap(choice)
+		if m.recommended == i {
+			description += theme.SuccessStyle.Render(" рекомендуется")
+		}
+
+		body += fmt.Sprintf("%s [%s] %s\n", cursor, checked, description)
+	}
+
+	if m.confirmActive {
+		body += "\nВы уверены, что хотите выбрать " + theme.SelectedStyle.Render(describeSwap(m.choices[m.selected])) + "?\n"
+		confirmOptions := []string{"Да", "Отмена"}
+		for i, option := range confirmOptions {
+			cursor := " "
+			if m.confirmCursor == i {
+				cursor = theme.CursorStyle.Render(">")
+			}
+			body += fmt.Sprintf("%s %s\n", cursor, option)
+		}
+	}
+
+	footer := fmt.Sprintf("\nОперативная память: %.1f ГБ. Для гибернации нужен раздел подкачки.\n", float64(m.memoryMiB)/1024)
+	return header + "\n\n" + body + theme.SuccessInfoStyle.Render(footer)
+}
@@ -0,0 +1,131 @@
+package installer
+
+import (
+	"atomic-actions/models/installer/theme"
+	"fmt"
+	tea "github.com/charmbracelet/bubbletea"
+	"os"
+	"strings"
+)
+
+// mokPasswordMaxLength ограничение mokutil на длину пароля регистрации ключа
+const mokPasswordMaxLength = 256
+
+type MokPassword struct {
+	Result         string // Пароль регистрации ключа MOK
+	Done           bool   // Ввод подтверждён
+	password       string // Пароль
+	passwordRepeat string // Подтверждение пароля
+	focusedField   int    // Фокус: 0 - пароль, 1 - подтверждение
+	cursor         int    // Позиция курсора в поле ввода
+	errorMessage   string // Ошибка проверки пароля
+}
+
+// RunMokPasswordStep запрашивает одноразовый пароль для подтверждения ключа MOK в MokManager, ok=false — ввод отменён
+func RunMokPasswordStep() (string, bool) {
+	p := tea.NewProgram(InitialMokPassword())
+	model, err := p.Run()
+	if err != nil {
+		fmt.Printf("Ошибка во время ввода пароля MOK: %v\n", err)
+		os.Exit(1)
+	}
+
+	mokModel := model.(MokPassword)
+	return mokModel.Result, mokModel.Done
+}
+
+func InitialMokPassword() MokPassword {
+	return MokPassword{}
+}
+
+// validateMokPassword проверяет пароль перед передачей в mokutil --import
+func validateMokPassword(password string, repeat string) error {
+	switch {
+	case password == "":
+		return fmt.Errorf("пароль не может быть пустым")
+	case len(password) > mokPasswordMaxLength:
+		return fmt.Errorf("пароль длиннее %d символов", mokPasswordMaxLength)
+	case password != repeat:
+		return fmt.Errorf("пароли не совпадают")
+	}
+	return nil
+}
+
+func (m MokPassword) Init() tea.Cmd {
+	return nil
+}
+
+func (m MokPassword) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			return m, tea.Quit
+		case "tab", "down", "shift+tab", "up":
+			m.focusedField = 1 - m.focusedField
+			m.cursor = len(m.fieldValue())
+		case "left":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "right":
+			if m.cursor < len(m.fieldValue()) {
+				m.cursor++
+			}
+		case "enter":
+			if m.focusedField == 0 {
+				m.focusedField = 1
+				m.cursor = len(m.passwordRepeat)
+				return m, nil
+			}
+			if err := validateMokPassword(m.password, m.passwordRepeat); err != nil {
+				m.errorMessage = err.Error()
+				return m, nil
+			}
+			m.Result = m.password
+			m.Done = true
+			return m, tea.Quit
+		default:
+			value, cursor := handleTextInputWithCursor(m.fieldValue(), msg, m.cursor)
+			if m.focusedField == 0 {
+				m.password = value
+			} else {
+				m.passwordRepeat = value
+			}
+			m.cursor = cursor
+			m.errorMessage = ""
+		}
+	}
+	return m, nil
+}
+
+// fieldValue возвращает значение поля в фокусе
+func (m MokPassword) fieldValue() string {
+	if m.focusedField == 0 {
+		return m.password
+	}
+	return m.passwordRepeat
+}
+
+func (m MokPassword) View() string {
+	header := theme.HeaderStyle.Render("Пароль регистрации ключа MOK")
+
+	renderField := func(field int, label string, value string) string {
+		masked := strings.Repeat("*", len(value))
+		if m.focusedField == field {
+			masked = masked[:m.cursor] + theme.CursorStyle.Render("|") + masked[m.cursor:]
+		}
+		return label + "\n" + theme.InputStyle.Render(masked) + "\n"
+	}
+
+	body := "Одноразовый пароль для подтверждения ключа в MokManager при следующей загрузке.\n" +
+		"Он не связан с паролем пользователя. MokManager использует английскую раскладку клавиатуры.\n\n"
+	body += renderField(0, "Пароль:", m.password)
+	body += renderField(1, "Повторите пароль:", m.passwordRepeat)
+
+	footer := "\nTab — следующее поле, Enter — продолжить, Esc — отмена.\n"
+	if m.errorMessage != "" {
+		footer += theme.ErrorStyle.Render(m.errorMessage) + "\n"
+	}
+	return header + "\n\n" + body + theme.FooterStyle.Render(footer)
+}
@@ -0,0 +1,150 @@
+package installer
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// swapFilePath путь к файлу подкачки в установленной системе
+const swapFilePath = "/var/swap/swapfile"
+
+// minSwapPartitionMiB минимальный размер раздела подкачки, меньше предлагать нет смысла
+const minSwapPartitionMiB = 1024
+
+// SwapConfig выбранный способ подкачки
+type SwapConfig struct {
+	Mode      string // none, partition, file или zram
+	SizeMiB   int    // Размер раздела или файла подкачки
+	Hibernate bool   // Размер с запасом под гибернацию и аргумент resume= для ядра
+}
+
+// swapModes допустимые значения SwapConfig.Mode
+var swapModes = []string{"none", "partition", "file", "zram"}
+
+// getMemoryMiB возвращает объём оперативной памяти из /proc/meminfo
+func getMemoryMiB() (int, error) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, fmt.Errorf("ошибка чтения /proc/meminfo: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return 0, fmt.Errorf("ошибка разбора MemTotal: %v", err)
+			}
+			return kb / 1024, nil
+		}
+	}
+
+	return 0, fmt.Errorf("MemTotal не найден в /proc/meminfo")
+}
+
+// recommendedSwapMiB рассчитывает размер подкачки по объёму памяти.
+// Для гибернации нужен объём памяти плюс запас sqrt(RAM), иначе — не больше 8 ГБ.
+func recommendedSwapMiB(memoryMiB int, hibernate bool) int {
+	memoryGiB := float64(memoryMiB) / 1024
+	if hibernate {
+		return int(math.Ceil(memoryGiB+math.Sqrt(memoryGiB))) * 1024
+	}
+	if memoryMiB < 2048 {
+		return memoryMiB * 2
+	}
+	return min(memoryMiB, 8192)
+}
+
+// validateSwapConfig проверяет сочетание режима подкачки и гибернации
+func validateSwapConfig(swap SwapConfig) error {
+	valid := false
+	for _, mode := range swapModes {
+		if swap.Mode == mode {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("неизвестный режим подкачки: %s", swap.Mode)
+	}
+
+	// Для файла подкачки resume= требует смещения resume_offset, поэтому гибернация поддерживается только с разделом
+	if swap.Hibernate && swap.Mode != "partition" {
+		return fmt.Errorf("гибернация доступна только с разделом подкачки")
+	}
+
+	if (swap.Mode == "partition" || swap.Mode == "file") && swap.SizeMiB <= 0 {
+		return fmt.Errorf("не задан размер подкачки")
+	}
+	return nil
+}
+
+// swapSubvolume подтом btrfs для файла подкачки: файл подкачки на btrfs должен быть без copy-on-write
+func swapSubvolume() BtrfsSubvolume {
+	return BtrfsSubvolume{Name: "@swap", MountPoint: filepath.Dir(swapFilePath), NoCOW: true}
+}
+
+// configureSwap создаёт файл подкачки или конфигурацию zram в установленной системе
+func configureSwap(swap SwapConfig, rootFileSystem FilesystemDriver, rootPartition string, ostreeDeployPath string) error {
+	switch swap.Mode {
+	case "file":
+		log.Printf("Создание файла подкачки %s размером %d МиБ...\n", swapFilePath, swap.SizeMiB)
+		return rootFileSystem.CreateSwapFile(rootPartition, ostreeDeployPath, swap.SizeMiB)
+	case "zram":
+		return configureZram(ostreeDeployPath)
+	}
+	return nil
+}
+
+// configureZram записывает конфигурацию zram-generator в /etc развёртывания
+func configureZram(ostreeDeployPath string) error {
+	generatorPath := filepath.Join(ostreeDeployPath, "usr/lib/systemd/system-generators/zram-generator")
+	if _, err := os.Stat(generatorPath); os.IsNotExist(err) {
+		log.Println("Предупреждение: zram-generator не найден в образе, конфигурация zram не будет применена.")
+	}
+
+	configPath := filepath.Join(ostreeDeployPath, "etc/systemd/zram-generator.conf")
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return fmt.Errorf("ошибка создания каталога %s: %v", filepath.Dir(configPath), err)
+	}
+
+	content := "# Auto generate from atomic-actions installer\n" +
+		"[zram0]\n" +
+		"zram-size = min(ram / 2, 8192)\n" +
+		"compression-algorithm = zstd\n"
+
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("ошибка записи %s: %v", configPath, err)
+	}
+
+	log.Printf("Файл %s успешно создан.\n", configPath)
+	return nil
+}
+
+// createStaterootSwapFile создаёт файл подкачки в /var stateroot, используется файловыми системами без подтомов
+func createStaterootSwapFile(ostreeDeployPath string, sizeMiB int) error {
+	swapFile := filepath.Join(ostreeDeployPath, "../../var", strings.TrimPrefix(swapFilePath, "/var/"))
+	if err := os.MkdirAll(filepath.Dir(swapFile), 0700); err != nil {
+		return fmt.Errorf("ошибка создания каталога %s: %v", filepath.Dir(swapFile), err)
+	}
+
+	if err := runCommand("fallocate", "-l", fmt.Sprintf("%dMiB", sizeMiB), swapFile); err != nil {
+		return fmt.Errorf("ошибка выделения места под файл подкачки: %v", err)
+	}
+
+	if err := os.Chmod(swapFile, 0600); err != nil {
+		return fmt.Errorf("ошибка изменения прав файла подкачки: %v", err)
+	}
+
+	if err := runCommand("mkswap", swapFile); err != nil {
+		return fmt.Errorf("ошибка форматирования файла подкачки: %v", err)
+	}
+	return nil
+}
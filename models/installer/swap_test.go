@@ -0,0 +1,28 @@
+package installer
+
+import "testing"
+
+func TestRecommendedSwapMiB(t *testing.T) {
+	tests := []struct {
+		name      string
+		memoryMiB int
+		hibernate bool
+		want      int
+	}{
+		{name: "мало памяти: удвоенный объём", memoryMiB: 1024, want: 2048},
+		{name: "граница 2 ГБ", memoryMiB: 2048, want: 2048},
+		{name: "средний объём: равен памяти", memoryMiB: 4096, want: 4096},
+		{name: "много памяти: не больше 8 ГБ", memoryMiB: 32768, want: 8192},
+		{name: "гибернация 4 ГБ: память и корень из неё", memoryMiB: 4096, hibernate: true, want: 6 * 1024},
+		{name: "гибернация 16 ГБ", memoryMiB: 16384, hibernate: true, want: 20 * 1024},
+		{name: "гибернация округляется вверх до ГБ", memoryMiB: 8192, hibernate: true, want: 11 * 1024},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recommendedSwapMiB(tt.memoryMiB, tt.hibernate); got != tt.want {
+				t.Errorf("recommendedSwapMiB(%d, %t) = %d, ожидалось %d", tt.memoryMiB, tt.hibernate, got, tt.want)
+			}
+		})
+	}
+}
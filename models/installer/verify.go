@@ -0,0 +1,327 @@
+package installer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultReportName имя файла отчёта проверки во временном каталоге, если --report не указан
+const defaultReportName = "atomic-actions-install-report.json"
+
+// VerificationCheck результат одной проверки установленной системы
+type VerificationCheck struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// VerificationReport машиночитаемый отчёт проверки установленной системы
+type VerificationReport struct {
+	Time       string              `json:"time"`
+	Image      string              `json:"image"`
+	Digest     string              `json:"digest,omitempty"`
+	Disk       string              `json:"disk"`
+	BootMode   string              `json:"boot_mode"`
+	Filesystem string              `json:"filesystem"`
+	Passed     bool                `json:"passed"`
+	Checks     []VerificationCheck `json:"checks"`
+}
+
+// blsEntry запись загрузчика из /boot/loader/entries
+type blsEntry struct {
+	Path    string
+	Version int
+	Linux   string
+	Initrd  []string
+	Options string
+}
+
+// add добавляет результат проверки в отчёт
+func (r *VerificationReport) add(name string, err error) {
+	check := VerificationCheck{Name: name, Passed: err == nil}
+	if err != nil {
+		check.Message = err.Error()
+		r.Passed = false
+		log.Printf("Проверка %s: ошибка: %v\n", name, err)
+	} else {
+		log.Printf("Проверка %s: успешно\n", name)
+	}
+	r.Checks = append(r.Checks, check)
+}
+
+// newVerificationReport создаёт отчёт без проверок, он считается пройденным до первой ошибки
+func newVerificationReport(config *InstallConfig) *VerificationReport {
+	return &VerificationReport{
+		Time:       time.Now().Format(time.RFC3339),
+		Image:      config.Image,
+		Digest:     config.ImageDigest,
+		Disk:       config.Disk,
+		BootMode:   config.BootMode,
+		Filesystem: config.Filesystem.Name(),
+		Passed:     true,
+	}
+}
+
+// verifyInstallation монтирует установленную систему только для чтения и проверяет, что она загрузится
+func verifyInstallation(config *InstallConfig) (*VerificationReport, error) {
+	log.Println("Проверка установленной системы...")
+
+	report := newVerificationReport(config)
+	partitions, err := getNamedPartitions(config.Disk, config.BootMode)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения разделов: %v", err)
+	}
+
+	mountPoint := "/mnt/verify"
+	bootMountPoint := filepath.Join(mountPoint, "boot")
+	efiMountPoint := filepath.Join(bootMountPoint, "efi")
+
+	if err := mountDisk(partitions["root"].Path, mountPoint, joinMountOptions("ro", config.Filesystem.RootMountOptions())); err != nil {
+		return nil, fmt.Errorf("ошибка монтирования root раздела: %v", err)
+	}
+	defer func() {
+		if err := releaseMountPoint(mountPoint); err != nil {
+			log.Printf("Предупреждение: %v\n", err)
+		}
+	}()
+
+	if err := mountDisk(partitions["boot"].Path, bootMountPoint, "ro"); err != nil {
+		return nil, fmt.Errorf("ошибка монтирования boot раздела: %v", err)
+	}
+	defer unmountOrWarn(bootMountPoint)
+
+	if err := mountDisk(partitions["efi"].Path, efiMountPoint, "ro"); err != nil {
+		return nil, fmt.Errorf("ошибка монтирования EFI раздела: %v", err)
+	}
+	defer unmountOrWarn(efiMountPoint)
+
+	ostreeDeployPath, err := findOstreeDeployPath(mountPoint)
+	report.add("deployment", err)
+	if err != nil {
+		return report, nil
+	}
+
+	entries, err := readBlsEntries(bootMountPoint)
+	if err == nil {
+		err = verifyDefaultDeployment(mountPoint, ostreeDeployPath, entries)
+	}
+	report.add("default-deployment", err)
+
+	if err == nil {
+		report.add("kernel", verifyBootFiles(bootMountPoint, entries[0]))
+	}
+
+	report.add("fstab", verifyFstab(ostreeDeployPath, partitions))
+	report.add("user", verifyUser(ostreeDeployPath, config.User.Username))
+	report.add("localtime", verifyLocaltime(ostreeDeployPath))
+	report.add("bootloader", verifyBootloader(config, partitions, efiMountPoint))
+
+	return report, nil
+}
+
+// readBlsEntries читает записи BLS, первой идёт запись с наибольшей версией — она загружается по умолчанию
+func readBlsEntries(bootPath string) ([]blsEntry, error) {
+	files, err := filepath.Glob(filepath.Join(bootPath, "loader/entries/*.conf"))
+	if err != nil || len(files) == 0 {
+		return nil, fmt.Errorf("записи загрузчика в /boot/loader/entries не найдены")
+	}
+
+	var entries []blsEntry
+	for _, path := range files {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения %s: %v", path, err)
+		}
+
+		entry := blsEntry{Path: path}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			key, value, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+			value = strings.TrimSpace(value)
+			switch key {
+			case "version":
+				entry.Version, _ = strconv.Atoi(value)
+			case "linux":
+				entry.Linux = value
+			case "initrd":
+				entry.Initrd = append(entry.Initrd, value)
+			case "options":
+				entry.Options = value
+			}
+		}
+		file.Close()
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Version > entries[j].Version })
+	return entries, nil
+}
+
+// verifyDefaultDeployment проверяет, что аргумент ostree= записи по умолчанию указывает на найденное развёртывание
+func verifyDefaultDeployment(rootPath string, ostreeDeployPath string, entries []blsEntry) error {
+	var ostreeArg string
+	for _, option := range strings.Fields(entries[0].Options) {
+		if strings.HasPrefix(option, "ostree=") {
+			ostreeArg = strings.TrimPrefix(option, "ostree=")
+		}
+	}
+	if ostreeArg == "" {
+		return fmt.Errorf("в %s нет аргумента ostree=", filepath.Base(entries[0].Path))
+	}
+
+	target, err := filepath.EvalSymlinks(filepath.Join(rootPath, ostreeArg))
+	if err != nil {
+		return fmt.Errorf("ссылка %s не разрешается: %v", ostreeArg, err)
+	}
+
+	deployPath, err := filepath.EvalSymlinks(ostreeDeployPath)
+	if err != nil {
+		return fmt.Errorf("ошибка разрешения пути %s: %v", ostreeDeployPath, err)
+	}
+
+	if target != deployPath {
+		return fmt.Errorf("развёртывание по умолчанию %s не совпадает с установленным %s", target, deployPath)
+	}
+	return nil
+}
+
+// verifyBootFiles проверяет, что ядро и initramfs из записи BLS существуют на boot разделе
+func verifyBootFiles(bootPath string, entry blsEntry) error {
+	if entry.Linux == "" {
+		return fmt.Errorf("в %s не указано ядро", filepath.Base(entry.Path))
+	}
+	if len(entry.Initrd) == 0 {
+		return fmt.Errorf("в %s не указан initramfs", filepath.Base(entry.Path))
+	}
+
+	for _, path := range append([]string{entry.Linux}, entry.Initrd...) {
+		if _, err := os.Stat(filepath.Join(bootPath, path)); err != nil {
+			return fmt.Errorf("файл %s не найден на boot разделе", path)
+		}
+	}
+	return nil
+}
+
+// verifyFstab проверяет, что все UUID в fstab принадлежат разделам установленного диска
+func verifyFstab(ostreeDeployPath string, partitions map[string]PartitionInfo) error {
+	data, err := os.ReadFile(filepath.Join(ostreeDeployPath, "etc/fstab"))
+	if err != nil {
+		return fmt.Errorf("ошибка чтения fstab: %v", err)
+	}
+
+	uuids := make(map[string]bool)
+	for _, partition := range partitions {
+		if uuid := getUUID(partition.Path); uuid != "" {
+			uuids[uuid] = true
+		}
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "UUID=") {
+			continue
+		}
+		uuid := strings.TrimPrefix(fields[0], "UUID=")
+		if !uuids[uuid] {
+			return fmt.Errorf("UUID %s для %s не найден среди разделов диска", uuid, fields[1])
+		}
+	}
+	return nil
+}
+
+// verifyUser проверяет запись пользователя в passwd и shadow и его домашний каталог в /var/home
+func verifyUser(ostreeDeployPath string, userName string) error {
+	passwd, err := findColonRecord(filepath.Join(ostreeDeployPath, "etc/passwd"), userName)
+	if err != nil {
+		return err
+	}
+	if len(passwd) < 7 || passwd[5] != filepath.Join("/var/home", userName) {
+		return fmt.Errorf("домашний каталог пользователя %s не в /var/home", userName)
+	}
+
+	shadow, err := findColonRecord(filepath.Join(ostreeDeployPath, "etc/shadow"), userName)
+	if err != nil {
+		return err
+	}
+	if len(shadow) < 2 || shadow[1] == "" || strings.HasPrefix(shadow[1], "!") || shadow[1] == "*" {
+		return fmt.Errorf("пароль пользователя %s не установлен", userName)
+	}
+	return nil
+}
+
+// findColonRecord возвращает поля строки файла вида passwd по имени в первом поле
+func findColonRecord(path string, name string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s: %v", path, err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Split(line, ":")
+		if fields[0] == name {
+			return fields, nil
+		}
+	}
+	return nil, fmt.Errorf("пользователь %s не найден в %s", name, filepath.Base(path))
+}
+
+// verifyLocaltime проверяет, что /etc/localtime указывает на существующий файл зоны внутри развёртывания
+func verifyLocaltime(ostreeDeployPath string) error {
+	localtimePath := filepath.Join(ostreeDeployPath, "etc/localtime")
+	target, err := os.Readlink(localtimePath)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения ссылки /etc/localtime: %v", err)
+	}
+
+	if !filepath.IsAbs(target) {
+		target = filepath.Join("/etc", target)
+	}
+	if _, err := os.Stat(filepath.Join(ostreeDeployPath, target)); err != nil {
+		return fmt.Errorf("/etc/localtime указывает на несуществующий файл %s", target)
+	}
+	return nil
+}
+
+// verifyBootloader проверяет загрузчик EFI на ESP и BIOS GRUB для соответствующих режимов загрузки
+func verifyBootloader(config *InstallConfig, partitions map[string]PartitionInfo, efiMountPoint string) error {
+	if config.BootMode != "LEGACY" {
+		if _, err := findEfiLoader(efiMountPoint); err != nil {
+			return err
+		}
+	}
+
+	if usesBiosLayout(config.BootMode) {
+		coreImage, err := readBlockPrefix(partitions["bios"].Path, 512)
+		if err != nil {
+			return err
+		}
+		if isZeroed(coreImage) {
+			return fmt.Errorf("BIOS Boot Partition %s пуст", partitions["bios"].Path)
+		}
+	}
+	return nil
+}
+
+// writeVerificationReport сохраняет отчёт в JSON, по умолчанию во временный каталог
+func writeVerificationReport(report *VerificationReport, path string) (string, error) {
+	if path == "" {
+		path = filepath.Join(os.TempDir(), defaultReportName)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("ошибка формирования отчёта: %v", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("ошибка записи отчёта %s: %v", path, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,206 @@
+package installer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestFile создаёт файл вместе с каталогами
+func writeTestFile(t *testing.T, path string, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkError сравнивает ошибку с ожидаемой частью текста, пусто — ошибки нет
+func checkError(t *testing.T, err error, wantErr string) {
+	t.Helper()
+	if wantErr == "" {
+		if err != nil {
+			t.Fatalf("неожиданная ошибка: %v", err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Fatalf("ошибка = %v, ожидалась содержащая %q", err, wantErr)
+	}
+}
+
+func TestReadBlsEntries(t *testing.T) {
+	bootPath := t.TempDir()
+	if _, err := readBlsEntries(bootPath); err == nil {
+		t.Fatal("ожидалась ошибка для boot раздела без записей")
+	}
+
+	writeTestFile(t, filepath.Join(bootPath, "loader/entries/ostree-1-alt.conf"),
+		"title Alt Atomic\nversion 1\nlinux /ostree/alt-1/vmlinuz\ninitrd /ostree/alt-1/initramfs.img\noptions root=UUID=1 ostree=/ostree/boot.1/alt/a/0\n")
+	writeTestFile(t, filepath.Join(bootPath, "loader/entries/ostree-2-alt.conf"),
+		"title Alt Atomic\nversion 2\nlinux /ostree/alt-2/vmlinuz\ninitrd /ostree/alt-2/initramfs.img\ninitrd /ostree/alt-2/microcode.img\noptions  root=UUID=1 ostree=/ostree/boot.1/alt/b/0\n")
+
+	entries, err := readBlsEntries(bootPath)
+	if err != nil {
+		t.Fatalf("неожиданная ошибка: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("прочитано записей: %d, ожидалось 2", len(entries))
+	}
+
+	first := entries[0]
+	if first.Version != 2 || first.Linux != "/ostree/alt-2/vmlinuz" || len(first.Initrd) != 2 {
+		t.Errorf("первой должна идти запись с наибольшей версией, получено %+v", first)
+	}
+	if first.Options != "root=UUID=1 ostree=/ostree/boot.1/alt/b/0" {
+		t.Errorf("options = %q", first.Options)
+	}
+}
+
+func TestVerifyDefaultDeployment(t *testing.T) {
+	rootPath := t.TempDir()
+	deployPath := filepath.Join(rootPath, "ostree/deploy/alt/deploy/abc.0")
+	otherPath := filepath.Join(rootPath, "ostree/deploy/alt/deploy/old.0")
+	for _, path := range []string{deployPath, otherPath, filepath.Join(rootPath, "ostree/boot.1/alt/a")} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("../../../deploy/alt/deploy/abc.0", filepath.Join(rootPath, "ostree/boot.1/alt/a/0")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		options string
+		deploy  string
+		wantErr string
+	}{
+		{name: "ссылка на установленное развёртывание", options: "rw ostree=/ostree/boot.1/alt/a/0", deploy: deployPath},
+		{name: "нет аргумента ostree", options: "rw quiet", deploy: deployPath, wantErr: "нет аргумента ostree="},
+		{name: "ссылка не разрешается", options: "ostree=/ostree/boot.1/alt/b/0", deploy: deployPath, wantErr: "не разрешается"},
+		{name: "другое развёртывание", options: "ostree=/ostree/boot.1/alt/a/0", deploy: otherPath, wantErr: "не совпадает"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := []blsEntry{{Path: "ostree-1-alt.conf", Options: tt.options}}
+			checkError(t, verifyDefaultDeployment(rootPath, tt.deploy, entries), tt.wantErr)
+		})
+	}
+}
+
+func TestVerifyBootFiles(t *testing.T) {
+	bootPath := t.TempDir()
+	writeTestFile(t, filepath.Join(bootPath, "ostree/alt-1/vmlinuz"), "")
+	writeTestFile(t, filepath.Join(bootPath, "ostree/alt-1/initramfs.img"), "")
+
+	tests := []struct {
+		name    string
+		entry   blsEntry
+		wantErr string
+	}{
+		{name: "файлы на месте", entry: blsEntry{Linux: "/ostree/alt-1/vmlinuz", Initrd: []string{"/ostree/alt-1/initramfs.img"}}},
+		{name: "нет ядра в записи", entry: blsEntry{Initrd: []string{"/ostree/alt-1/initramfs.img"}}, wantErr: "не указано ядро"},
+		{name: "нет initramfs в записи", entry: blsEntry{Linux: "/ostree/alt-1/vmlinuz"}, wantErr: "не указан initramfs"},
+		{name: "файла нет на разделе", entry: blsEntry{Linux: "/ostree/alt-2/vmlinuz", Initrd: []string{"/ostree/alt-1/initramfs.img"}}, wantErr: "/ostree/alt-2/vmlinuz не найден"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkError(t, verifyBootFiles(bootPath, tt.entry), tt.wantErr)
+		})
+	}
+}
+
+func TestVerifyUser(t *testing.T) {
+	deployPath := t.TempDir()
+	writeTestFile(t, filepath.Join(deployPath, "etc/passwd"),
+		"root:x:0:0:root:/root:/bin/bash\n"+
+			"user:x:1000:1000::/var/home/user:/bin/bash\n"+
+			"old:x:1001:1001::/home/old:/bin/bash\n"+
+			"locked:x:1002:1002::/var/home/locked:/bin/bash\n")
+	writeTestFile(t, filepath.Join(deployPath, "etc/shadow"),
+		"root:*:19000::::::\n"+
+			"user:$y$j9T$hash:19000:0:99999:7:::\n"+
+			"old:$y$j9T$hash:19000:0:99999:7:::\n"+
+			"locked:!:19000:0:99999:7:::\n")
+
+	tests := []struct {
+		name    string
+		user    string
+		wantErr string
+	}{
+		{name: "пользователь с паролем", user: "user"},
+		{name: "нет пользователя", user: "nobody", wantErr: "не найден в passwd"},
+		{name: "домашний каталог не в /var/home", user: "old", wantErr: "не в /var/home"},
+		{name: "заблокированный пароль", user: "locked", wantErr: "пароль пользователя locked не установлен"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkError(t, verifyUser(deployPath, tt.user), tt.wantErr)
+		})
+	}
+}
+
+func TestVerifyLocaltime(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string // Цель ссылки /etc/localtime, пусто — ссылки нет
+		wantErr string
+	}{
+		{name: "абсолютная ссылка", target: "/usr/share/zoneinfo/Europe/Moscow"},
+		{name: "относительная ссылка", target: "../usr/share/zoneinfo/Europe/Moscow"},
+		{name: "нет файла зоны", target: "/usr/share/zoneinfo/Mars/Olympus", wantErr: "несуществующий файл"},
+		{name: "нет ссылки", wantErr: "ошибка чтения ссылки"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployPath := t.TempDir()
+			writeTestFile(t, filepath.Join(deployPath, "usr/share/zoneinfo/Europe/Moscow"), "")
+			if err := os.MkdirAll(filepath.Join(deployPath, "etc"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.target != "" {
+				if err := os.Symlink(tt.target, filepath.Join(deployPath, "etc/localtime")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			checkError(t, verifyLocaltime(deployPath), tt.wantErr)
+		})
+	}
+}
+
+func TestVerificationReportAdd(t *testing.T) {
+	report := &VerificationReport{Passed: true}
+	report.add("boot", nil)
+	if !report.Passed {
+		t.Fatal("успешная проверка не должна проваливать отчёт")
+	}
+
+	report.add("fstab", errors.New("UUID не найден"))
+	report.add("user", nil)
+	if report.Passed {
+		t.Error("отчёт с проваленной проверкой считается успешным")
+	}
+
+	want := []VerificationCheck{
+		{Name: "boot", Passed: true},
+		{Name: "fstab", Passed: false, Message: "UUID не найден"},
+		{Name: "user", Passed: true},
+	}
+	if len(report.Checks) != len(want) {
+		t.Fatalf("проверок %d, ожидалось %d", len(report.Checks), len(want))
+	}
+	for i := range want {
+		if report.Checks[i] != want[i] {
+			t.Errorf("проверка %d = %+v, ожидалось %+v", i, report.Checks[i], want[i])
+		}
+	}
+}
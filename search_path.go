@@ -0,0 +1,156 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	actionsSubdir    = "atomic-actions/actions"      // Каталог действий внутри каталога данных XDG
+	adminActionsPath = "/etc/atomic-actions/actions" // Действия администратора, перекрывают системные
+	actionsPathEnv   = "ATOMIC_ACTIONS_PATH"         // Список каталогов через ":" для разработки
+)
+
+// systemDataDirs системные каталоги данных, значение XDG_DATA_DIRS по умолчанию
+var systemDataDirs = []string{"/usr/local/share", "/usr/share"}
+
+// ActionRoot каталог, в котором ищутся действия
+type ActionRoot struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"` // path (ATOMIC_ACTIONS_PATH), user, admin, xdg (XDG_DATA_DIRS) или system
+}
+
+// actionRoots каталоги поиска действий в порядке убывания приоритета, заполняется при запуске
+var actionRoots []ActionRoot
+
+// searchRoots возвращает каталоги поиска действий в порядке убывания приоритета.
+// При приоритете system (по умолчанию) первыми идут /etc/atomic-actions/actions и системные каталоги, а каталоги
+// из переменных окружения — XDG_DATA_DIRS, ATOMIC_ACTIONS_PATH и XDG_DATA_HOME — только после них: окружение
+// задаёт пользователь, и под sudo оно не должно подменять системные действия.
+// При приоритете user порядок обратный: ATOMIC_ACTIONS_PATH, XDG_DATA_HOME, /etc/atomic-actions/actions, XDG_DATA_DIRS, системные.
+func searchRoots(precedence string) []ActionRoot {
+	var paths []ActionRoot
+	for _, path := range filepath.SplitList(os.Getenv(actionsPathEnv)) {
+		if path != "" {
+			paths = append(paths, ActionRoot{Path: path, Kind: "path"})
+		}
+	}
+
+	user := ActionRoot{Path: filepath.Join(xdgDataHome(), actionsSubdir), Kind: "user"}
+	admin := ActionRoot{Path: adminActionsPath, Kind: "admin"}
+
+	var xdg []ActionRoot
+	for _, dir := range xdgDataDirs() {
+		xdg = append(xdg, ActionRoot{Path: filepath.Join(dir, actionsSubdir), Kind: "xdg"})
+	}
+
+	var system []ActionRoot
+	for _, dir := range systemDataDirs {
+		system = append(system, ActionRoot{Path: filepath.Join(dir, actionsSubdir), Kind: "system"})
+	}
+	// Прежний системный каталог ищется всегда, даже если системные каталоги данных изменятся
+	system = append(system, ActionRoot{Path: systemActionsPath, Kind: "system"})
+
+	var roots []ActionRoot
+	if precedence == "user" {
+		roots = append(roots, paths...)
+		roots = append(roots, user, admin)
+		roots = append(roots, xdg...)
+		roots = append(roots, system...)
+	} else {
+		roots = append(roots, admin)
+		roots = append(roots, system...)
+		roots = append(roots, xdg...)
+		roots = append(roots, paths...)
+		roots = append(roots, user)
+	}
+	return uniqueRoots(roots)
+}
+
+// xdgDataHome возвращает XDG_DATA_HOME, по умолчанию ~/.local/share
+func xdgDataHome() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(os.Getenv("HOME"), ".local/share")
+}
+
+// xdgDataDirs возвращает каталоги из XDG_DATA_DIRS, кроме системных: они ищутся всегда и с приоритетом системных
+func xdgDataDirs() []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv("XDG_DATA_DIRS")) {
+		// Относительные пути спецификация XDG предписывает игнорировать
+		if !filepath.IsAbs(dir) || isSystemDataDir(dir) {
+			continue
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// isSystemDataDir сообщает, что каталог — один из systemDataDirs
+func isSystemDataDir(dir string) bool {
+	for _, system := range systemDataDirs {
+		if filepath.Clean(dir) == system {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueRoots убирает повторы каталогов, остаётся вхождение с наибольшим приоритетом
+func uniqueRoots(roots []ActionRoot) []ActionRoot {
+	seen := make(map[string]bool)
+	var unique []ActionRoot
+	for _, root := range roots {
+		root.Path = filepath.Clean(root.Path)
+		if !seen[root.Path] {
+			seen[root.Path] = true
+			unique = append(unique, root)
+		}
+	}
+	return unique
+}
+
+// loadActionRoots загружает действия из всех каталогов, действие из более приоритетного каталога перекрывает одноимённые
+func loadActionRoots(roots []ActionRoot) map[string]Command {
+	commands := make(map[string]Command)
+	for _, root := range roots {
+		commands = mergeCommands(commands, loadActionsWithDescriptions(root.Path))
+	}
+	return commands
+}
+
+// sourceKind возвращает происхождение команды по каталогу поиска, в котором она найдена: path, user, admin, xdg, system или builtin
+func sourceKind(source string) string {
+	if source == "" {
+		return "builtin"
+	}
+
+	kind, longest := "system", -1
+	for _, root := range actionRoots {
+		if (source == root.Path || strings.HasPrefix(source, root.Path+string(filepath.Separator))) && len(root.Path) > longest {
+			kind, longest = root.Kind, len(root.Path)
+		}
+	}
+	return kind
+}
+
+// describeKind возвращает происхождение каталога для справки
+func describeKind(kind string) string {
+	switch kind {
+	case "path":
+		return actionsPathEnv
+	case "user":
+		return "пользовательское"
+	case "admin":
+		return "администратора"
+	case "xdg":
+		return "XDG_DATA_DIRS"
+	case "builtin":
+		return "встроенная команда"
+	default:
+		return "системное"
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearchRoots(t *testing.T) {
+	tests := []struct {
+		name       string
+		precedence string
+		env        map[string]string
+		want       []ActionRoot
+	}{
+		{
+			name:       "system по умолчанию: пользовательский каталог последний",
+			precedence: "system",
+			env:        map[string]string{"HOME": "/home/u"},
+			want: []ActionRoot{
+				{Path: adminActionsPath, Kind: "admin"},
+				{Path: "/usr/local/share/atomic-actions/actions", Kind: "system"},
+				{Path: "/usr/share/atomic-actions/actions", Kind: "system"},
+				{Path: "/home/u/.local/share/atomic-actions/actions", Kind: "user"},
+			},
+		},
+		{
+			name:       "user: пользовательский каталог первый",
+			precedence: "user",
+			env:        map[string]string{"HOME": "/home/u"},
+			want: []ActionRoot{
+				{Path: "/home/u/.local/share/atomic-actions/actions", Kind: "user"},
+				{Path: adminActionsPath, Kind: "admin"},
+				{Path: "/usr/local/share/atomic-actions/actions", Kind: "system"},
+				{Path: "/usr/share/atomic-actions/actions", Kind: "system"},
+			},
+		},
+		{
+			name:       "user: ATOMIC_ACTIONS_PATH выше всех, пустые элементы пропускаются",
+			precedence: "user",
+			env: map[string]string{
+				"HOME":          "/home/u",
+				actionsPathEnv:  "/dev/a::/dev/b",
+				"XDG_DATA_HOME": "/data",
+				"XDG_DATA_DIRS": "/opt/share",
+			},
+			want: []ActionRoot{
+				{Path: "/dev/a", Kind: "path"},
+				{Path: "/dev/b", Kind: "path"},
+				{Path: "/data/atomic-actions/actions", Kind: "user"},
+				{Path: adminActionsPath, Kind: "admin"},
+				{Path: "/opt/share/atomic-actions/actions", Kind: "xdg"},
+				{Path: "/usr/local/share/atomic-actions/actions", Kind: "system"},
+				{Path: "/usr/share/atomic-actions/actions", Kind: "system"},
+			},
+		},
+		{
+			name:       "system: каталоги из окружения после системных",
+			precedence: "system",
+			env: map[string]string{
+				"HOME":          "/home/u",
+				actionsPathEnv:  "/dev/a",
+				"XDG_DATA_DIRS": "/opt/share:/usr/share",
+			},
+			want: []ActionRoot{
+				{Path: adminActionsPath, Kind: "admin"},
+				{Path: "/usr/local/share/atomic-actions/actions", Kind: "system"},
+				{Path: "/usr/share/atomic-actions/actions", Kind: "system"},
+				{Path: "/opt/share/atomic-actions/actions", Kind: "xdg"},
+				{Path: "/dev/a", Kind: "path"},
+				{Path: "/home/u/.local/share/atomic-actions/actions", Kind: "user"},
+			},
+		},
+		{
+			name:       "системные каталоги в XDG_DATA_DIRS остаются системными",
+			precedence: "user",
+			env: map[string]string{
+				"HOME":          "/home/u",
+				"XDG_DATA_DIRS": "/usr/share/:/opt/share",
+			},
+			want: []ActionRoot{
+				{Path: "/home/u/.local/share/atomic-actions/actions", Kind: "user"},
+				{Path: adminActionsPath, Kind: "admin"},
+				{Path: "/opt/share/atomic-actions/actions", Kind: "xdg"},
+				{Path: "/usr/local/share/atomic-actions/actions", Kind: "system"},
+				{Path: "/usr/share/atomic-actions/actions", Kind: "system"},
+			},
+		},
+		{
+			name:       "относительные пути XDG игнорируются",
+			precedence: "system",
+			env: map[string]string{
+				"HOME":          "/home/u",
+				"XDG_DATA_HOME": "data",
+				"XDG_DATA_DIRS": "share:/opt/share",
+			},
+			want: []ActionRoot{
+				{Path: adminActionsPath, Kind: "admin"},
+				{Path: "/usr/local/share/atomic-actions/actions", Kind: "system"},
+				{Path: "/usr/share/atomic-actions/actions", Kind: "system"},
+				{Path: "/opt/share/atomic-actions/actions", Kind: "xdg"},
+				{Path: "/home/u/.local/share/atomic-actions/actions", Kind: "user"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(actionsPathEnv, "")
+			t.Setenv("XDG_DATA_HOME", "")
+			t.Setenv("XDG_DATA_DIRS", "")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			if got := searchRoots(tt.precedence); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("searchRoots(%q) = %v, ожидалось %v", tt.precedence, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUniqueRoots(t *testing.T) {
+	tests := []struct {
+		name  string
+		roots []ActionRoot
+		want  []ActionRoot
+	}{
+		{name: "пусто", roots: nil, want: nil},
+		{
+			name: "остаётся первое вхождение",
+			roots: []ActionRoot{
+				{Path: "/a", Kind: "path"},
+				{Path: "/b", Kind: "user"},
+				{Path: "/a", Kind: "system"},
+			},
+			want: []ActionRoot{{Path: "/a", Kind: "path"}, {Path: "/b", Kind: "user"}},
+		},
+		{
+			name: "пути сравниваются после очистки",
+			roots: []ActionRoot{
+				{Path: "/usr/share/actions/", Kind: "system"},
+				{Path: "/usr//share/./actions", Kind: "system"},
+			},
+			want: []ActionRoot{{Path: "/usr/share/actions", Kind: "system"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uniqueRoots(tt.roots); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("uniqueRoots() = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
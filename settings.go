@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// settingsVersion текущая версия схемы settings.json.
+// Версия 1 — commands как список строк с общим описанием, версия 2 — описание каждой подкоманды.
+const settingsVersion = 2
+
+type ActionSettings struct {
+	Version     int               `json:"version"`
+	Commands    []CommandSettings `json:"commands"`
+	Description string            `json:"description"`
+	Usage       string            `json:"usage"`    // Использование действия без подкоманд
+	Args        []ArgSettings     `json:"args"`     // Аргументы действия без подкоманд
+	Examples    []string          `json:"examples"` // Примеры действия без подкоманд
+	Sudo        bool              `json:"sudo"`     // Значение по умолчанию для подкоманд
+}
+
+// CommandSettings описание подкоманды действия
+type CommandSettings struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Usage       string        `json:"usage"` // Строка использования без "atomic-actions <действие>", например "install <пакет>..."
+	Args        []ArgSettings `json:"args"`
+	Examples    []string      `json:"examples"`
+	Sudo        *bool         `json:"sudo"` // Не задано — используется sudo действия
+}
+
+// ArgSettings описание позиционного аргумента
+type ArgSettings struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Required    bool     `json:"required"`
+	Variadic    bool     `json:"variadic"` // Принимает несколько значений, допустим только последним
+	Choices     []string `json:"choices"`  // Допустимые значения, пусто — любые
+}
+
+// UnmarshalJSON принимает подкоманду как объект (версия 2) или как строку с именем (версия 1)
+func (c *CommandSettings) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*c = CommandSettings{Name: name}
+		return nil
+	}
+
+	// Отдельный тип без метода UnmarshalJSON, чтобы не уйти в рекурсию
+	type plain CommandSettings
+	var command plain
+	if err := json.Unmarshal(data, &command); err != nil {
+		return err
+	}
+	*c = CommandSettings(command)
+	return nil
+}
+
+func parseSettings(settingsPath string) (*ActionSettings, error) {
+	data, err := os.ReadFile(settingsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var settings ActionSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("ошибка разбора JSON: %v", err)
+	}
+
+	// Файлы без поля version написаны по первой версии схемы
+	if settings.Version == 0 {
+		settings.Version = 1
+	}
+
+	if err := settings.validate(); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// validate проверяет описание действия, ошибка указывает на поле с проблемой
+func (s *ActionSettings) validate() error {
+	if s.Version > settingsVersion {
+		return fmt.Errorf("версия схемы %d не поддерживается, последняя поддерживаемая — %d", s.Version, settingsVersion)
+	}
+	if s.Description == "" {
+		return fmt.Errorf("не заполнено поле description")
+	}
+	if err := validateArgs(s.Args); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for i, command := range s.Commands {
+		if command.Name == "" {
+			return fmt.Errorf("commands[%d]: не указано имя подкоманды", i)
+		}
+		if strings.ContainsAny(command.Name, " \t") || strings.HasPrefix(command.Name, "-") {
+			return fmt.Errorf("commands[%d]: недопустимое имя подкоманды %q", i, command.Name)
+		}
+		if seen[command.Name] {
+			return fmt.Errorf("commands[%d]: подкоманда %s указана повторно", i, command.Name)
+		}
+		seen[command.Name] = true
+
+		if s.Version < 2 && (command.Description != "" || command.Usage != "" || len(command.Args) > 0 || len(command.Examples) > 0 || command.Sudo != nil) {
+			return fmt.Errorf("commands[%d]: описание подкоманд поддерживается с версии схемы 2", i)
+		}
+		if err := validateArgs(command.Args); err != nil {
+			return fmt.Errorf("commands[%d] (%s): %v", i, command.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateArgs проверяет порядок и описание позиционных аргументов
+func validateArgs(args []ArgSettings) error {
+	optional := false
+	for i, arg := range args {
+		if arg.Name == "" {
+			return fmt.Errorf("args[%d]: не указано имя аргумента", i)
+		}
+		if arg.Variadic && i != len(args)-1 {
+			return fmt.Errorf("args[%d] (%s): аргумент с variadic должен быть последним", i, arg.Name)
+		}
+		if arg.Required && optional {
+			return fmt.Errorf("args[%d] (%s): обязательный аргумент после необязательного", i, arg.Name)
+		}
+		optional = optional || !arg.Required
+
+		for _, choice := range arg.Choices {
+			if choice == "" {
+				return fmt.Errorf("args[%d] (%s): пустое значение в choices", i, arg.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// subcommand возвращает описание подкоманды с учётом значений действия по умолчанию
+func (s *ActionSettings) subcommand(command CommandSettings) CommandSettings {
+	if command.Description == "" {
+		command.Description = s.Description
+	}
+	if command.Sudo == nil {
+		command.Sudo = &s.Sudo
+	}
+	return command
+}
+
+// action возвращает описание действия без подкоманд в виде подкоманды с пустым именем
+func (s *ActionSettings) action() CommandSettings {
+	return CommandSettings{
+		Description: s.Description,
+		Usage:       s.Usage,
+		Args:        s.Args,
+		Examples:    s.Examples,
+		Sudo:        &s.Sudo,
+	}
+}
+
+// usage возвращает строку использования, по умолчанию собирается из аргументов: <обязательный> [необязательный]...
+func (c CommandSettings) usage() string {
+	if c.Usage != "" {
+		return c.Usage
+	}
+
+	parts := []string{}
+	if c.Name != "" {
+		parts = append(parts, c.Name)
+	}
+	for _, arg := range c.Args {
+		name := arg.Name
+		if len(arg.Choices) > 0 {
+			name = strings.Join(arg.Choices, "|")
+		}
+		if arg.Variadic {
+			name += "..."
+		}
+		if arg.Required {
+			parts = append(parts, "<"+name+">")
+		} else {
+			parts = append(parts, "["+name+"]")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// checkArgs проверяет переданные аргументы по описанию: обязательные указаны, значения из choices
+func (c CommandSettings) checkArgs(args []string) error {
+	for i, arg := range c.Args {
+		values := []string{}
+		if i < len(args) {
+			values = args[i : i+1]
+			if arg.Variadic {
+				values = args[i:]
+			}
+		}
+
+		if len(values) == 0 {
+			if arg.Required {
+				return fmt.Errorf("не указан обязательный аргумент %s", arg.Name)
+			}
+			continue
+		}
+
+		if len(arg.Choices) == 0 {
+			continue
+		}
+		for _, value := range values {
+			if !containsString(arg.Choices, value) {
+				return fmt.Errorf("недопустимое значение %s для аргумента %s, допустимо: %s", value, arg.Name, strings.Join(arg.Choices, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// containsString сообщает, есть ли строка в списке
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommandSettingsUnmarshalJSON(t *testing.T) {
+	sudo := true
+	tests := []struct {
+		name    string
+		data    string
+		want    []CommandSettings
+		wantErr bool
+	}{
+		{
+			name: "версия 1: строки",
+			data: `["install", "remove"]`,
+			want: []CommandSettings{{Name: "install"}, {Name: "remove"}},
+		},
+		{
+			name: "версия 2: объекты",
+			data: `[{"name": "install", "description": "Установка", "args": [{"name": "пакет", "required": true}], "sudo": true}]`,
+			want: []CommandSettings{{
+				Name:        "install",
+				Description: "Установка",
+				Args:        []ArgSettings{{Name: "пакет", Required: true}},
+				Sudo:        &sudo,
+			}},
+		},
+		{
+			name: "строки и объекты вместе",
+			data: `["list", {"name": "install"}]`,
+			want: []CommandSettings{{Name: "list"}, {Name: "install"}},
+		},
+		{
+			name:    "число вместо подкоманды",
+			data:    `[1]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []CommandSettings
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ошибка = %v, ожидалась ошибка: %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("получено %+v, ожидалось %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActionSettingsValidate(t *testing.T) {
+	sudo := true
+	tests := []struct {
+		name     string
+		settings ActionSettings
+		wantErr  string // Часть текста ошибки, пусто — ошибки нет
+	}{
+		{
+			name:     "версия 1 без подкоманд",
+			settings: ActionSettings{Version: 1, Description: "Действие"},
+		},
+		{
+			name: "версия 2 с описанием подкоманд",
+			settings: ActionSettings{Version: 2, Description: "Действие", Commands: []CommandSettings{
+				{Name: "install", Description: "Установка", Sudo: &sudo},
+			}},
+		},
+		{
+			name:     "версия новее поддерживаемой",
+			settings: ActionSettings{Version: settingsVersion + 1, Description: "Действие"},
+			wantErr:  "не поддерживается",
+		},
+		{
+			name:     "нет описания",
+			settings: ActionSettings{Version: 2},
+			wantErr:  "description",
+		},
+		{
+			name:     "пустое имя подкоманды",
+			settings: ActionSettings{Version: 2, Description: "Действие", Commands: []CommandSettings{{}}},
+			wantErr:  "не указано имя подкоманды",
+		},
+		{
+			name:     "пробел в имени подкоманды",
+			settings: ActionSettings{Version: 2, Description: "Действие", Commands: []CommandSettings{{Name: "a b"}}},
+			wantErr:  "недопустимое имя",
+		},
+		{
+			name:     "имя подкоманды как флаг",
+			settings: ActionSettings{Version: 2, Description: "Действие", Commands: []CommandSettings{{Name: "-v"}}},
+			wantErr:  "недопустимое имя",
+		},
+		{
+			name:     "повтор подкоманды",
+			settings: ActionSettings{Version: 2, Description: "Действие", Commands: []CommandSettings{{Name: "a"}, {Name: "a"}}},
+			wantErr:  "указана повторно",
+		},
+		{
+			name:     "описание подкоманды в версии 1",
+			settings: ActionSettings{Version: 1, Description: "Действие", Commands: []CommandSettings{{Name: "a", Description: "А"}}},
+			wantErr:  "с версии схемы 2",
+		},
+		{
+			name: "ошибка в аргументах подкоманды",
+			settings: ActionSettings{Version: 2, Description: "Действие", Commands: []CommandSettings{
+				{Name: "a", Args: []ArgSettings{{Name: ""}}},
+			}},
+			wantErr: "commands[0] (a)",
+		},
+		{
+			name:     "ошибка в аргументах действия",
+			settings: ActionSettings{Version: 2, Description: "Действие", Args: []ArgSettings{{Name: ""}}},
+			wantErr:  "не указано имя аргумента",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.settings.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("неожиданная ошибка: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ошибка = %v, ожидалась содержащая %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []ArgSettings
+		wantErr string
+	}{
+		{name: "без аргументов"},
+		{
+			name: "обязательный, необязательный и variadic",
+			args: []ArgSettings{{Name: "a", Required: true}, {Name: "b"}, {Name: "c", Variadic: true}},
+		},
+		{
+			name:    "без имени",
+			args:    []ArgSettings{{Required: true}},
+			wantErr: "не указано имя аргумента",
+		},
+		{
+			name:    "variadic не последний",
+			args:    []ArgSettings{{Name: "a", Variadic: true}, {Name: "b"}},
+			wantErr: "должен быть последним",
+		},
+		{
+			name:    "обязательный после необязательного",
+			args:    []ArgSettings{{Name: "a"}, {Name: "b", Required: true}},
+			wantErr: "обязательный аргумент после необязательного",
+		},
+		{
+			name:    "пустое значение choices",
+			args:    []ArgSettings{{Name: "a", Choices: []string{"x", ""}}},
+			wantErr: "пустое значение в choices",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateArgs(tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("неожиданная ошибка: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ошибка = %v, ожидалась содержащая %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommandSettingsCheckArgs(t *testing.T) {
+	command := CommandSettings{Args: []ArgSettings{
+		{Name: "режим", Required: true, Choices: []string{"on", "off"}},
+		{Name: "пакет", Variadic: true, Choices: []string{"vim", "git"}},
+	}}
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "только обязательный", args: []string{"on"}},
+		{name: "несколько значений variadic", args: []string{"off", "vim", "git"}},
+		{name: "нет обязательного", wantErr: "не указан обязательный аргумент режим"},
+		{name: "значение не из choices", args: []string{"auto"}, wantErr: "недопустимое значение auto"},
+		{name: "variadic не из choices", args: []string{"on", "vim", "emacs"}, wantErr: "недопустимое значение emacs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := command.checkArgs(tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("неожиданная ошибка: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ошибка = %v, ожидалась содержащая %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCommandSettingsUsage(t *testing.T) {
+	tests := []struct {
+		name    string
+		command CommandSettings
+		want    string
+	}{
+		{name: "пусто", command: CommandSettings{}, want: ""},
+		{name: "только имя", command: CommandSettings{Name: "update"}, want: "update"},
+		{
+			name:    "своя строка использования",
+			command: CommandSettings{Name: "install", Usage: "install <пакет>...", Args: []ArgSettings{{Name: "x"}}},
+			want:    "install <пакет>...",
+		},
+		{
+			name: "аргументы",
+			command: CommandSettings{Name: "install", Args: []ArgSettings{
+				{Name: "режим", Required: true, Choices: []string{"on", "off"}},
+				{Name: "пакет", Variadic: true},
+			}},
+			want: "install <on|off> [пакет...]",
+		},
+		{
+			name:    "обязательный variadic без имени подкоманды",
+			command: CommandSettings{Args: []ArgSettings{{Name: "пакет", Required: true, Variadic: true}}},
+			want:    "<пакет...>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.command.usage(); got != tt.want {
+				t.Errorf("usage() = %q, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// whichCommand встроенная команда which, показывает, какой каталог и скрипт будут выполнены для команды
+func whichCommand(commands map[string]Command, precedence string) Command {
+	sudo := false
+	settings := &CommandSettings{
+		Description: "Показывает, откуда загружена команда и какой скрипт будет выполнен.",
+		Args: []ArgSettings{
+			{Name: "команда", Description: "Действие и подкоманда", Required: true, Variadic: true},
+		},
+		Examples: []string{"atomic-actions which apt install"},
+		Sudo:     &sudo,
+	}
+
+	return Command{
+		Action:      "which",
+		Description: settings.Description,
+		Settings:    settings,
+		Handler: func(args []string) {
+			if err := settings.checkArgs(args); err != nil {
+				fmt.Println(err)
+				fmt.Println("Использование: atomic-actions which " + settings.usage())
+				os.Exit(2)
+			}
+
+			args = resolveCommand(commands, args)
+			name := args[0]
+			command, exists := commands[name]
+			if !exists {
+				// Группа без указания подкоманды: каталог общий для всех подкоманд действия
+				n := commandPrefix(commands, args)
+				if n != len(args) {
+					printUnknownCommand(commands, args)
+					return
+				}
+				name = strings.Join(args, " ")
+				defer fmt.Printf("Подкоманды: %s\n", strings.Join(childrenOf(commands, name), ", "))
+
+				action, isAction := groupAction(commands, name)
+				if !isAction {
+					fmt.Printf("Команда: %s\nГруппа действий без собственного каталога\n", name)
+					return
+				}
+				command = action
+			}
+
+			fmt.Printf("Команда: %s\n", name)
+			fmt.Printf("Источник: %s\n", describeSource(command.Source))
+			if command.Source != "" {
+				script := filepath.Join(command.Source, "main.sh")
+				if exists {
+					script = strings.TrimSpace(script + " " + command.Settings.Name)
+				}
+				fmt.Printf("Скрипт: %s\n", script)
+			}
+			if command.Settings != nil {
+				fmt.Printf("Права суперпользователя: %s\n", describeSudo(*command.Settings.Sudo))
+			}
+			for _, shadowed := range command.Shadows {
+				fmt.Printf("Перекрывает: %s\n", describeSource(shadowed))
+			}
+			fmt.Printf("Приоритет: %s (%s)\n", precedence, precedenceEnv)
+		},
+	}
+}